@@ -0,0 +1,69 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/config"
+	"github.com/9506hqwy/vmomi-exporter/pkg/exporter"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+func getGenerateOptions(cmd *cobra.Command) ([]func(o *exporter.GenerateOptions), error) {
+	preset, err := cmd.Flags().GetString("preset")
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := cmd.Flags().GetInt32("level")
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := cmd.Flags().GetStringSlice("entity-type")
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := getGenerateRoots(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	moTypes := []vmomi.ManagedEntityType{}
+	for _, t := range types {
+		moTypes = append(moTypes, vmomi.ManagedEntityType(t))
+	}
+
+	return []func(o *exporter.GenerateOptions){
+		exporter.WithGeneratePreset(preset),
+		exporter.WithGenerateLevel(level),
+		exporter.WithGenerateTypes(moTypes),
+		exporter.WithGenerateRoots(roots),
+	}, nil
+}
+
+func getGenerateRoots(cmd *cobra.Command) ([]config.Root, error) {
+	datacenters, err := cmd.Flags().GetStringSlice("datacenter")
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := cmd.Flags().GetStringSlice("cluster")
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []config.Root{}
+	for _, name := range datacenters {
+		roots = append(roots, config.Root{Type: vmomi.ManagedEntityTypeDatacenter, Name: name})
+	}
+
+	for _, name := range clusters {
+		roots = append(roots, config.Root{
+			Type: vmomi.ManagedEntityTypeClusterComputeResource,
+			Name: name,
+		})
+	}
+
+	return roots, nil
+}
@@ -1,17 +1,22 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	"github.com/9506hqwy/vmomi-exporter/pkg/config"
 	"github.com/9506hqwy/vmomi-exporter/pkg/exporter"
 	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+	"github.com/9506hqwy/vmomi-exporter/pkg/output"
 	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
 	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi/propertyex"
 )
@@ -20,6 +25,7 @@ var version = "<version>"
 var commit = "<commit>"
 
 const rootFolerName = ""
+const noPassword = ""
 
 //revive:disable:deep-exit
 
@@ -57,8 +63,32 @@ var configCmd = &cobra.Command{
 			log.Fatalf("EncodeConfig error: %v", err)
 		}
 
-		_, err = fmt.Print(conf)
+		if err := printDocument(conf); err != nil {
+			log.Fatalf("Print error: %v", err)
+		}
+	},
+}
+
+var configGenerateCmd = &cobra.Command{
+	Use:     "generate",
+	Short:   "VMOMI Exporter Config Generate",
+	Long:    "VMOMI Exporter Config Generate",
+	Version: fmt.Sprintf("%s\nCommit: %s", version, commit),
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx := context.Background()
+		ctx = fromArgument(ctx)
+
+		opts, err := getGenerateOptions(cmd)
 		if err != nil {
+			log.Fatalf("Get arguments: %v", err)
+		}
+
+		conf, err := exporter.GenerateConfig(ctx, opts...)
+		if err != nil {
+			log.Fatalf("GenerateConfig error: %v", err)
+		}
+
+		if err := printDocument(conf); err != nil {
 			log.Fatalf("Print error: %v", err)
 		}
 	},
@@ -82,23 +112,42 @@ var counterCmd = &cobra.Command{
 			return (*counters)[a].ID < (*counters)[b].ID
 		})
 
-		cnts := []config.Counter{}
-		for _, c := range *counters {
-			cnt := config.Counter{
-				Group:  c.Group,
-				Name:   c.Name,
-				Rollup: c.Rollup,
+		encodeConfig := func() (string, error) {
+			cnts := []config.Counter{}
+			for _, c := range *counters {
+				cnt := config.Counter{
+					Group:  c.Group,
+					Name:   c.Name,
+					Rollup: c.Rollup,
+				}
+				cnts = append(cnts, cnt)
 			}
-			cnts = append(cnts, cnt)
+
+			return config.EncodeCounters(&cnts)
 		}
 
-		conf, err := config.EncodeCounters(&cnts)
-		if err != nil {
-			log.Fatalf("EncodeCounters error: %v", err)
+		if err := printList(encodeConfig, counterColumns, *counters); err != nil {
+			log.Fatalf("Print error: %v", err)
 		}
+	},
+}
+
+var dashboardCmd = &cobra.Command{
+	Use:     "dashboard",
+	Short:   "VMOMI Exporter Dashboard",
+	Long:    "VMOMI Exporter Dashboard",
+	Version: fmt.Sprintf("%s\nCommit: %s", version, commit),
+	Run: func(_ *cobra.Command, _ []string) {
+		ctx := context.Background()
+		ctx = fromArgument(ctx)
 
-		_, err = fmt.Print(conf)
+		cfg, err := config.GetConfig(ctx)
 		if err != nil {
+			log.Fatalf("GetConfig error: %v", err)
+		}
+
+		// Grafana imports only JSON.
+		if err := output.WriteJSON(os.Stdout, exporter.NewDashboard(cfg)); err != nil {
 			log.Fatalf("Print error: %v", err)
 		}
 	},
@@ -153,22 +202,20 @@ var entityCmd = &cobra.Command{
 			return (*entities)[a].Type < (*entities)[b].Type
 		})
 
-		roots := []config.Root{}
-		for _, e := range *entities {
-			r := config.Root{
-				Type: e.Type,
-				Name: e.Name,
+		encodeConfig := func() (string, error) {
+			roots := []config.Root{}
+			for _, e := range *entities {
+				r := config.Root{
+					Type: e.Type,
+					Name: e.Name,
+				}
+				roots = append(roots, r)
 			}
-			roots = append(roots, r)
-		}
 
-		conf, err := config.EncodeRoots(&roots)
-		if err != nil {
-			log.Fatalf("EncodeRoots error: %v", err)
+			return config.EncodeRoots(&roots)
 		}
 
-		_, err = fmt.Print(conf)
-		if err != nil {
+		if err := printList(encodeConfig, entityColumns, *entities); err != nil {
 			log.Fatalf("Print error: %v", err)
 		}
 	},
@@ -192,25 +239,23 @@ var instanceCmd = &cobra.Command{
 			return (*instances)[a].EntityType < (*instances)[b].EntityType
 		})
 
-		inses := []config.Instance{}
-		for _, c := range *instances {
-			cnt := config.Instance{
-				EntityType: c.EntityType,
-				EntityID:   c.EntityID,
-				EntityName: c.EntityName,
-				Instance:   c.Instance,
-				CounterID:  c.CounterID,
+		encodeConfig := func() (string, error) {
+			inses := []config.Instance{}
+			for _, c := range *instances {
+				cnt := config.Instance{
+					EntityType: c.EntityType,
+					EntityID:   c.EntityID,
+					EntityName: c.EntityName,
+					Instance:   c.Instance,
+					CounterID:  c.CounterID,
+				}
+				inses = append(inses, cnt)
 			}
-			inses = append(inses, cnt)
-		}
 
-		conf, err := config.EncodeInstances(&inses)
-		if err != nil {
-			log.Fatalf("EncodeInstances error: %v", err)
+			return config.EncodeInstances(&inses)
 		}
 
-		_, err = fmt.Print(conf)
-		if err != nil {
+		if err := printList(encodeConfig, instanceColumns, *instances); err != nil {
 			log.Fatalf("Print error: %v", err)
 		}
 	},
@@ -258,11 +303,8 @@ var intervalCmd = &cobra.Command{
 			log.Fatalf("GetIntervalInfo error: %v", err)
 		}
 
-		for _, interval := range intervals {
-			_, err = fmt.Printf("%d (Current: %v)\n", interval.ID, interval.Current)
-			if err != nil {
-				log.Fatalf("Print error: %v", err)
-			}
+		if err := printList(nil, intervalColumns, intervals); err != nil {
+			log.Fatalf("Print error: %v", err)
 		}
 	},
 }
@@ -273,58 +315,114 @@ var perfCmd = &cobra.Command{
 	Long:    "VMOMI Exporter Performance",
 	Version: fmt.Sprintf("%s\nCommit: %s", version, commit),
 	Run: func(cmd *cobra.Command, _ []string) {
-		entityTypeStr, err := cmd.Flags().GetString("entity-type")
-		if err != nil || entityTypeStr == "" {
-			log.Fatalf("Get entity-type error: %v", err)
+		ctx := context.Background()
+		ctx = fromArgument(ctx)
+
+		query, err := getSeriesQuery(ctx, cmd)
+		if err != nil {
+			log.Fatalf("Get arguments: %v", err)
 		}
 
-		entityID, err := cmd.Flags().GetString("entity-id")
-		if err != nil || entityID == "" {
-			log.Fatalf("Get entity-id error: %v", err)
+		metrics, err := vmomi.QuerySeries(ctx, *query)
+		if err != nil {
+			log.Fatalf("QuerySeries error: %v", err)
 		}
 
-		entityType := vmomi.ManagedEntityType(entityTypeStr)
+		if err := printList(nil, metricColumns, metrics); err != nil {
+			log.Fatalf("Print error: %v", err)
+		}
+	},
+}
+
+var entityTreeCmd = &cobra.Command{
+	Use:     "tree",
+	Short:   "VMOMI Exporter Entity Tree",
+	Long:    "VMOMI Exporter Entity Tree",
+	Version: fmt.Sprintf("%s\nCommit: %s", version, commit),
+	Run: func(cmd *cobra.Command, _ []string) {
+		format, err := getTreeFormat()
+		if err != nil {
+			log.Fatalf("Get output error: %v", err)
+		}
+
+		ctx := context.Background()
+		ctx = fromArgument(ctx)
 
-		counterID, err := cmd.Flags().GetInt32("counter")
-		if err != nil || counterID == 0 {
-			log.Fatalf("Get counter error: %v", err)
+		root, err := vmomi.GetEntityTree(ctx)
+		if err != nil {
+			log.Fatalf("GetEntityTree error: %v", err)
 		}
 
-		interval, err := cmd.Flags().GetInt32("interval")
-		if err != nil || interval == 0 {
-			log.Fatalf("Get interval error: %v", err)
+		nodes, err := selectSubtree(cmd, root)
+		if err != nil {
+			log.Fatalf("Get arguments: %v", err)
 		}
 
-		entity := &vmomi.Entity{
-			ID:   entityID,
-			Type: entityType,
+		if err := output.WriteTree(os.Stdout, format, nodes); err != nil {
+			log.Fatalf("Print error: %v", err)
 		}
+	},
+}
 
+var rulesCmd = &cobra.Command{
+	Use:     "rules",
+	Short:   "VMOMI Exporter Rules",
+	Long:    "VMOMI Exporter Rules",
+	Version: fmt.Sprintf("%s\nCommit: %s", version, commit),
+	Run: func(_ *cobra.Command, _ []string) {
 		ctx := context.Background()
 		ctx = fromArgument(ctx)
 
-		metrics, err := vmomi.QueryEntity(ctx, *entity, interval, counterID)
+		cfg, err := config.GetConfig(ctx)
 		if err != nil {
-			log.Fatalf("QueryEntity error: %v", err)
+			log.Fatalf("GetConfig error: %v", err)
 		}
 
-		for _, metric := range metrics {
-			_, err = fmt.Printf(
-				"%v\tinstance=%v\tinterval=%v\tcounter=%v\tvalue=%v\n",
-				metric.Timestamp,
-				metric.Instance,
-				metric.Interval,
-				metric.Counter.ID,
-				metric.Value)
-			if err != nil {
-				log.Fatalf("Print error: %v", err)
-			}
+		// Prometheus loads only YAML.
+		if err := output.WriteYAML(os.Stdout, exporter.NewRuleFile(ctx, cfg)); err != nil {
+			log.Fatalf("Print error: %v", err)
+		}
+	},
+}
+
+var scrapeCmd = &cobra.Command{
+	Use:     "scrape",
+	Short:   "VMOMI Exporter Scrape",
+	Long:    "VMOMI Exporter Scrape",
+	Version: fmt.Sprintf("%s\nCommit: %s", version, commit),
+	Run: func(_ *cobra.Command, _ []string) {
+		ctx := context.Background()
+		ctx = fromArgument(ctx)
+
+		started := time.Now()
+
+		timings, err := exporter.ScrapeOnce(ctx, os.Stdout, viper.GetString(outputKey))
+
+		if printErr := printTimings(timings, time.Since(started)); printErr != nil {
+			log.Fatalf("Print error: %v", printErr)
+		}
+
+		if err != nil {
+			log.Fatalf("Scrape error: %v", err)
 		}
 	},
 }
 
 //revive:enable:deep-exit
 
+func printTimings(timings *vmomi.PhaseTimings, total time.Duration) error {
+	// Write to stderr to keep stdout parsable.
+	for _, phase := range vmomi.Phases() {
+		seconds := timings.Get(phase).Seconds()
+		if _, err := fmt.Fprintf(os.Stderr, "%v\t%.3fs\n", phase, seconds); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(os.Stderr, "total\t%.3fs\n", total.Seconds())
+	return err
+}
+
 func getRootEntity(cmd *cobra.Command) (*config.Root, error) {
 	entityTypeStr, err := cmd.Flags().GetString("entity-type")
 	if err != nil {
@@ -336,20 +434,31 @@ func getRootEntity(cmd *cobra.Command) (*config.Root, error) {
 		return nil, err
 	}
 
-	if entityTypeStr == "" || entityName == "" {
-		root := config.Root{
-			Type: vmomi.ManagedEntityTypeFolder,
-			Name: rootFolerName,
-		}
-		return &root, nil
+	entityPath, err := cmd.Flags().GetString("entity-path")
+	if err != nil {
+		return nil, err
 	}
 
-	entityType := vmomi.ManagedEntityType(entityTypeStr)
+	entityID, err := cmd.Flags().GetString("entity-id")
+	if err != nil {
+		return nil, err
+	}
 
 	root := config.Root{
-		Type: entityType,
+		Type: vmomi.ManagedEntityType(entityTypeStr),
 		Name: entityName,
+		Path: entityPath,
+		ID:   entityID,
 	}
+
+	if entityPath == noValue && entityID == noValue &&
+		(entityTypeStr == noValue || entityName == noValue) {
+		root = config.Root{
+			Type: vmomi.ManagedEntityTypeFolder,
+			Name: rootFolerName,
+		}
+	}
+
 	return &root, nil
 }
 
@@ -359,66 +468,198 @@ func fromArgument(ctx context.Context) context.Context {
 	ctx = context.WithValue(ctx, flag.TargetURLKey{}, viper.GetString("target_url"))
 	ctx = context.WithValue(ctx, flag.TargetUserKey{}, viper.GetString("target_user"))
 	ctx = context.WithValue(ctx, flag.TargetPasswordKey{}, viper.GetString("target_password"))
+	ctx = context.WithValue(ctx, flag.TargetPasswordFileKey{}, viper.GetString("target_password_file"))
 	ctx = context.WithValue(ctx, flag.TargetNoVerifySSLKey{}, viper.GetBool("target_no_verify_ssl"))
+	ctx = context.WithValue(ctx, flag.TargetCAFileKey{}, viper.GetString("target_ca_file"))
+	ctx = context.WithValue(ctx, flag.TargetThumbprintKey{}, viper.GetString("target_thumbprint"))
+	ctx = context.WithValue(ctx, flag.TargetTLSServerNameKey{}, viper.GetString("target_tls_server_name"))
 	ctx = context.WithValue(ctx, flag.TargetTimeoutKey{}, viper.GetInt("target_timeout"))
+	ctx = context.WithValue(ctx, flag.TargetRetryMaxAttemptsKey{}, viper.GetInt("target_retry_max_attempts"))
+	ctx = context.WithValue(ctx, flag.TargetRetryInitialBackoffKey{}, viper.GetFloat64("target_retry_initial_backoff"))
+	ctx = context.WithValue(ctx, flag.TargetRetryMaxBackoffKey{}, viper.GetFloat64("target_retry_max_backoff"))
 	ctx = context.WithValue(ctx, flag.TargetMaxConcurrency{}, viper.GetInt("target_max_concurrency"))
 	ctx = context.WithValue(ctx, flag.TargetEntityChunkSize{}, viper.GetInt("target_entity_chunk_size"))
+	ctx = context.WithValue(ctx, flag.TargetMetricChunkSizeKey{}, viper.GetInt("target_metric_chunk_size"))
+	ctx = context.WithValue(ctx, flag.TargetQueryLatencyKey{}, viper.GetFloat64("target_query_latency"))
+	ctx = context.WithValue(ctx, flag.TargetPartialResultsKey{}, viper.GetBool("target_partial_results"))
+	ctx = context.WithValue(ctx, flag.TargetRecordDirKey{}, viper.GetString("target_record_dir"))
+	ctx = context.WithValue(ctx, flag.TargetReplayDirKey{}, viper.GetString("target_replay_dir"))
 	ctx = context.WithValue(ctx, flag.ExporterConfigKey{}, viper.GetString("config"))
 	ctx = context.WithValue(ctx, flag.ExporterURLKey{}, viper.GetString("url"))
 	ctx = context.WithValue(ctx, flag.LogLevelKey{}, viper.GetString("log_level"))
-	return ctx
+	ctx = context.WithValue(ctx, flag.CounterRefreshIntervalKey{}, viper.GetInt("counter_refresh_interval"))
+	ctx = context.WithValue(ctx, flag.ScrapeTimeoutOffsetKey{}, viper.GetFloat64("scrape_timeout_offset"))
+	return fromConfig(ctx)
 }
 
 //revive:enable:line-length-limit
 
+func fromConfig(ctx context.Context) context.Context {
+	cfg, err := config.GetConfig(ctx)
+	if err != nil {
+		// Report error when loading configuration for command.
+		return ctx
+	}
+
+	ctx = context.WithValue(ctx, vmomi.TransportKey{}, cfg.ToTransportOptions())
+
+	password := cmp.Or(viper.GetString("target_password"), viper.GetString("target_password_file"))
+	if password != noPassword {
+		// Prefer arguments to configuration file.
+		return ctx
+	}
+
+	cred := cfg.FindCredential(viper.GetString("target_url"))
+	if cred == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, vmomi.CredentialProviderKey{}, cred.Provider())
+}
+
 //revive:disable:add-constant
+//revive:disable:line-length-limit
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentPreRun = bindCommandFlags
+
+	initRootFlags()
+	initCommandFlags()
 
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGenerateCmd)
+	rootCmd.AddCommand(counterCmd)
+	rootCmd.AddCommand(dashboardCmd)
+	rootCmd.AddCommand(entityCmd)
+	entityCmd.AddCommand(entityTreeCmd)
+	rootCmd.AddCommand(instanceCmd)
+	rootCmd.AddCommand(intervalCmd)
+	rootCmd.AddCommand(perfCmd)
+	rootCmd.AddCommand(rulesCmd)
+	rootCmd.AddCommand(scrapeCmd)
+
+	bindRootFlags()
+}
+
+func initRootFlags() {
 	rootCmd.PersistentFlags().String("url", "https://127.0.0.1/sdk", "vSphere server URL.")
 	rootCmd.PersistentFlags().String("user", "", "vSphere server username.")
 	rootCmd.PersistentFlags().String("password", "", "vSphere server password.")
+	rootCmd.PersistentFlags().String("password-file", "", "vSphere server password file path.")
 	rootCmd.PersistentFlags().Bool("no-verify-ssl", false, "Skip SSL verification.")
+	rootCmd.PersistentFlags().String("ca-file", "", "CA certificate bundle file path.")
+	rootCmd.PersistentFlags().String("thumbprint", "", "vSphere server certificate thumbprint.")
+	rootCmd.PersistentFlags().String("tls-server-name", "", "Server name for SSL verification.")
 	rootCmd.PersistentFlags().Int("timeout", 10, "API call timeout seconds.")
+	rootCmd.PersistentFlags().Int("retry-max-attempts", 3, "API call max attempts.")
+	rootCmd.PersistentFlags().Float64("retry-initial-backoff", 1, "API call retry initial backoff seconds.")
+	rootCmd.PersistentFlags().Float64("retry-max-backoff", 30, "API call retry max backoff seconds.")
+	rootCmd.PersistentFlags().String("record", "", "Directory to record SOAP requests and responses.")
+	rootCmd.PersistentFlags().String("replay", "", "Directory to replay recorded SOAP responses.")
 	rootCmd.PersistentFlags().String("config", "", "Config file path.")
 	rootCmd.Flags().String("exporter", "127.0.0.1:9247", "Exporter URL.")
 	rootCmd.Flags().String("log-level", "INFO", "Log level.")
-	rootCmd.Flags().Int("max-concurrency", 5, "Max concurrency.")
-	rootCmd.Flags().Int("entity-chunk-size", 10, "Entity chunk size.")
+	rootCmd.Flags().Int("counter-refresh-interval", 3600, "Counter refresh interval seconds.")
+	initQueryFlags(rootCmd.Flags())
+}
+
+// initQueryFlags defines flags to query performance shared by exporter and scrape.
+func initQueryFlags(flags *pflag.FlagSet) {
+	flags.Int("max-concurrency", 5, "Max concurrency.")
+	flags.Int("entity-chunk-size", 10, "Entity chunk size.")
+	flags.Int("metric-chunk-size", 256, "Metric ID chunk size. (0 is unlimited)")
+	flags.Float64("target-query-latency", 5, "Target query latency seconds to tune concurrency. (0 is disabled)")
+	flags.Bool("partial-results", false, "Export succeeded chunks even if some chunks fail.")
+	flags.Float64("scrape-timeout-offset", 0.5, "Offset seconds to subtract from scrape timeout.")
+}
+
+func initCommandFlags() {
+	initOutputFlag(configCmd, output.FormatYAML, documentFormats())
+	initOutputFlag(configGenerateCmd, output.FormatYAML, documentFormats())
+	initOutputFlag(counterCmd, formatConfig, configListFormats())
+	initOutputFlag(entityCmd, formatConfig, configListFormats())
+	initOutputFlag(entityTreeCmd, output.FormatYAML, output.TreeFormats())
+	initOutputFlag(instanceCmd, formatConfig, configListFormats())
+	initOutputFlag(intervalCmd, output.FormatYAML, output.Formats())
+	initOutputFlag(perfCmd, output.FormatYAML, output.Formats())
+	initOutputFlag(scrapeCmd, exporter.FormatText, exporter.ScrapeFormats())
 
 	entityCmd.Flags().String("entity-type", "", "Entity type.")
-	entityCmd.Flags().String("entity-name", "", "Entity Name.")
+	entityCmd.Flags().String("entity-name", "", "Entity Name. (glob pattern)")
+	entityCmd.Flags().String("entity-path", "", "Entity inventory path. (glob pattern)")
+	entityCmd.Flags().String("entity-id", "", "Entity ID. (requires entity-type)")
 	entityCmd.Flags().Bool("ignore-datastore-vm", false, "Ignore datastore and vm relation.")
 	entityCmd.Flags().Bool("ignore-network-vm", false, "Ignore network and vm relation.")
 
+	configGenerateCmd.Flags().String("preset", exporter.PresetStandard, "Preset. (minimal, standard, full)")
+	configGenerateCmd.Flags().Int32("level", 0, "Max counter level. (0 is preset level)")
+	configGenerateCmd.Flags().StringSlice("entity-type", nil, "Entity type. (default preset types)")
+	configGenerateCmd.Flags().StringSlice("datacenter", nil, "Root datacenter name.")
+	configGenerateCmd.Flags().StringSlice("cluster", nil, "Root cluster name.")
+
+	entityTreeCmd.Flags().String("entity-type", "", "Subtree root entity type.")
+	entityTreeCmd.Flags().String("entity-id", "", "Subtree root entity ID.")
+	entityTreeCmd.Flags().String("entity-name", "", "Subtree root entity name.")
+
 	intervalCmd.Flags().String("entity-type", "", "Entity type.")
 	intervalCmd.Flags().String("entity-id", "", "Entity ID.")
 
 	perfCmd.Flags().String("entity-type", "", "Entity type.")
-	perfCmd.Flags().String("entity-id", "", "Entity ID.")
-	perfCmd.Flags().Int32("counter", 0, "Counter ID.")
-	perfCmd.Flags().Int32("interval", 0, "Interval.")
-
-	rootCmd.AddCommand(configCmd)
-	rootCmd.AddCommand(counterCmd)
-	rootCmd.AddCommand(entityCmd)
-	rootCmd.AddCommand(instanceCmd)
-	rootCmd.AddCommand(intervalCmd)
-	rootCmd.AddCommand(perfCmd)
+	perfCmd.Flags().StringSlice("entity-id", nil, "Entity ID.")
+	perfCmd.Flags().StringSlice("entity-name", nil, "Entity name.")
+	perfCmd.Flags().StringSlice("counter", nil, "Counter ID or name such as cpu.usage.average.")
+	perfCmd.Flags().Int32("interval", 0, "Interval. (0 is smallest available)")
+	perfCmd.Flags().String("start", "", "Start time in RFC3339.")
+	perfCmd.Flags().String("end", "", "End time in RFC3339.")
+	perfCmd.Flags().Duration("last", 0, "Duration to query until now such as 1h.")
+	perfCmd.Flags().StringSlice("instance", nil, "Instance. (default all instances)")
+	perfCmd.Flags().Int32("max-samples", 0, "Max samples per series of realtime interval. (0 is unlimited)")
+
+	initQueryFlags(scrapeCmd.Flags())
+}
 
+func bindRootFlags() {
 	viper.BindPFlag("target_url", rootCmd.PersistentFlags().Lookup("url"))
 	viper.BindPFlag("target_user", rootCmd.PersistentFlags().Lookup("user"))
 	viper.BindPFlag("target_password", rootCmd.PersistentFlags().Lookup("password"))
+	viper.BindPFlag("target_password_file", rootCmd.PersistentFlags().Lookup("password-file"))
 	viper.BindPFlag("target_no_verify_ssl", rootCmd.PersistentFlags().Lookup("no-verify-ssl"))
+	viper.BindPFlag("target_ca_file", rootCmd.PersistentFlags().Lookup("ca-file"))
+	viper.BindPFlag("target_thumbprint", rootCmd.PersistentFlags().Lookup("thumbprint"))
+	viper.BindPFlag("target_tls_server_name", rootCmd.PersistentFlags().Lookup("tls-server-name"))
 	viper.BindPFlag("target_timeout", rootCmd.PersistentFlags().Lookup("timeout"))
-	viper.BindPFlag("target_max_concurrency", rootCmd.Flags().Lookup("max-concurrency"))
-	viper.BindPFlag("target_entity_chunk_size", rootCmd.Flags().Lookup("entity-chunk-size"))
+	viper.BindPFlag("target_retry_max_attempts", rootCmd.PersistentFlags().Lookup("retry-max-attempts"))
+	viper.BindPFlag("target_retry_initial_backoff", rootCmd.PersistentFlags().Lookup("retry-initial-backoff"))
+	viper.BindPFlag("target_retry_max_backoff", rootCmd.PersistentFlags().Lookup("retry-max-backoff"))
+	viper.BindPFlag("target_record_dir", rootCmd.PersistentFlags().Lookup("record"))
+	viper.BindPFlag("target_replay_dir", rootCmd.PersistentFlags().Lookup("replay"))
 	viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
 	viper.BindPFlag("url", rootCmd.Flags().Lookup("exporter"))
 	viper.BindPFlag("log_level", rootCmd.Flags().Lookup("log-level"))
+	viper.BindPFlag("counter_refresh_interval", rootCmd.Flags().Lookup("counter-refresh-interval"))
 }
 
+// bindCommandFlags binds the flags defined by multiple commands to the running command.
+func bindCommandFlags(cmd *cobra.Command, _ []string) {
+	flags := map[string]string{
+		outputKey:                  outputKey,
+		"target_max_concurrency":   "max-concurrency",
+		"target_entity_chunk_size": "entity-chunk-size",
+		"target_metric_chunk_size": "metric-chunk-size",
+		"target_query_latency":     "target-query-latency",
+		"target_partial_results":   "partial-results",
+		"scrape_timeout_offset":    "scrape-timeout-offset",
+	}
+
+	for key, name := range flags {
+		if f := cmd.Flags().Lookup(name); f != nil {
+			viper.BindPFlag(key, f)
+		}
+	}
+}
+
+//revive:enable:line-length-limit
 //revive:enable:add-constant
 
 func initConfig() {
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/output"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+var counterColumns = []output.Column[vmomi.CounterInfo]{
+	{Name: "id", Value: func(c vmomi.CounterInfo) any { return c.ID }},
+	{Name: "group", Value: func(c vmomi.CounterInfo) any { return c.Group }},
+	{Name: "name", Value: func(c vmomi.CounterInfo) any { return c.Name }},
+	{Name: "rollup", Value: func(c vmomi.CounterInfo) any { return c.Rollup }},
+	{Name: "stats", Value: func(c vmomi.CounterInfo) any { return c.Stats }},
+	{Name: "unit", Value: func(c vmomi.CounterInfo) any { return c.Unit }},
+	{Name: "level", Value: func(c vmomi.CounterInfo) any { return c.Level }},
+	{Name: "summary", Value: func(c vmomi.CounterInfo) any { return c.NameSummary }},
+}
+
+var entityColumns = []output.Column[vmomi.Entity]{
+	{Name: "type", Value: func(e vmomi.Entity) any { return string(e.Type) }},
+	{Name: "id", Value: func(e vmomi.Entity) any { return e.ID }},
+	{Name: "name", Value: func(e vmomi.Entity) any { return e.Name }},
+}
+
+var instanceColumns = []output.Column[vmomi.InstanceInfo]{
+	{Name: "entity_type", Value: func(i vmomi.InstanceInfo) any { return string(i.EntityType) }},
+	{Name: "entity_id", Value: func(i vmomi.InstanceInfo) any { return i.EntityID }},
+	{Name: "entity_name", Value: func(i vmomi.InstanceInfo) any { return i.EntityName }},
+	{Name: "counter_id", Value: func(i vmomi.InstanceInfo) any { return i.CounterID }},
+	{Name: "instance", Value: func(i vmomi.InstanceInfo) any { return i.Instance }},
+}
+
+var intervalColumns = []output.Column[vmomi.IntervalID]{
+	{Name: "id", Value: func(i vmomi.IntervalID) any { return i.ID }},
+	{Name: "current", Value: func(i vmomi.IntervalID) any { return i.Current }},
+}
+
+var metricColumns = []output.Column[vmomi.Metric]{
+	{
+		Name:  "timestamp",
+		Value: func(m vmomi.Metric) any { return m.Timestamp.Format(time.RFC3339) },
+	},
+	{Name: "entity_type", Value: func(m vmomi.Metric) any { return string(m.Entity.Type) }},
+	{Name: "entity_id", Value: func(m vmomi.Metric) any { return m.Entity.ID }},
+	{Name: "entity_name", Value: func(m vmomi.Metric) any { return m.Entity.Name }},
+	{Name: "counter_id", Value: func(m vmomi.Metric) any { return m.Counter.ID }},
+	{Name: "counter", Value: func(m vmomi.Metric) any { return counterName(m.Counter) }},
+	{Name: "unit", Value: func(m vmomi.Metric) any { return m.Counter.Unit }},
+	{Name: "instance", Value: func(m vmomi.Metric) any { return m.Instance }},
+	{Name: "interval", Value: func(m vmomi.Metric) any { return m.Interval }},
+	{Name: "value", Value: func(m vmomi.Metric) any { return m.Value }},
+}
+
+func counterName(c vmomi.CounterInfo) string {
+	return fmt.Sprintf("%v.%v.%v", c.Group, c.Name, c.Rollup)
+}
+
+// formatConfig prints a snippet to paste into configuration file.
+const formatConfig = "config"
+
+// outputKey is the name of output flag and configuration key.
+const outputKey = "output"
+
+func documentFormats() []string {
+	return []string{output.FormatYAML, output.FormatJSON}
+}
+
+// configListFormats are accepted by list which prints a snippet of configuration.
+func configListFormats() []string {
+	return append([]string{formatConfig}, output.Formats()...)
+}
+
+// initOutputFlag defines output flag per command to show the formats accepted by it.
+func initOutputFlag(cmd *cobra.Command, defaultFormat string, formats []string) {
+	usage := fmt.Sprintf("Output format. (%v)", strings.Join(formats, ", "))
+	cmd.Flags().String(outputKey, defaultFormat, usage)
+}
+
+func getOutputFormat() (string, error) {
+	format := viper.GetString(outputKey)
+	if format == formatConfig {
+		return format, nil
+	}
+
+	if err := output.Validate(format); err != nil {
+		return "", err
+	}
+
+	return format, nil
+}
+
+func getTreeFormat() (string, error) {
+	format := viper.GetString(outputKey)
+	if !slices.Contains(output.TreeFormats(), format) {
+		return "", fmt.Errorf("invalid tree output format %v", format)
+	}
+
+	return format, nil
+}
+
+func printList[T any](
+	encodeConfig func() (string, error),
+	columns []output.Column[T],
+	items []T,
+) error {
+	format, err := getOutputFormat()
+	if err != nil {
+		return err
+	}
+
+	if format != formatConfig {
+		return output.Write(os.Stdout, format, columns, items)
+	}
+
+	if encodeConfig == nil {
+		return fmt.Errorf("not supported output format %v", format)
+	}
+
+	conf, err := encodeConfig()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Print(conf)
+	return err
+}
+
+// Print configuration as document because it is not list.
+func printDocument(conf string) error {
+	format, err := getOutputFormat()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case output.FormatYAML, formatConfig:
+		_, err = fmt.Print(conf)
+		return err
+	case output.FormatJSON:
+		var doc any
+		if err := yaml.Unmarshal([]byte(conf), &doc); err != nil {
+			return err
+		}
+
+		return output.WriteJSON(os.Stdout, doc)
+	default:
+		return fmt.Errorf("not supported output format %v", format)
+	}
+}
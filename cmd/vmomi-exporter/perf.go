@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	empty   = 0
+	noValue = ""
+)
+
+func getSeriesQuery(ctx context.Context, cmd *cobra.Command) (*vmomi.SeriesQuery, error) {
+	entities, err := getSeriesEntities(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	counters, err := getSeriesCounters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	query := vmomi.SeriesQuery{
+		Entities: entities,
+		Counters: counters,
+	}
+
+	if err := setSeriesPeriod(cmd, &query); err != nil {
+		return nil, err
+	}
+
+	if query.Interval, err = cmd.Flags().GetInt32("interval"); err != nil {
+		return nil, err
+	}
+
+	if query.Instances, err = cmd.Flags().GetStringSlice("instance"); err != nil {
+		return nil, err
+	}
+
+	if query.MaxSample, err = cmd.Flags().GetInt32("max-samples"); err != nil {
+		return nil, err
+	}
+
+	return &query, nil
+}
+
+func getSeriesEntities(ctx context.Context, cmd *cobra.Command) ([]vmomi.Entity, error) {
+	entityType, err := cmd.Flags().GetString("entity-type")
+	if err != nil {
+		return nil, err
+	}
+
+	if entityType == noValue {
+		return nil, errors.New("entity-type is required")
+	}
+
+	ids, err := cmd.Flags().GetStringSlice("entity-id")
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := cmd.Flags().GetStringSlice("entity-name")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == empty && len(names) == empty {
+		return nil, errors.New("entity-id or entity-name is required")
+	}
+
+	moType := vmomi.ManagedEntityType(entityType)
+	entities, err := vmomi.GetEntityFromRoot(ctx, []vmomi.ManagedEntityType{moType})
+	if err != nil {
+		return nil, err
+	}
+
+	return selectEntities(*entities, ids, names)
+}
+
+func selectEntities(entities []vmomi.Entity, ids, names []string) ([]vmomi.Entity, error) {
+	selected := slices.DeleteFunc(slices.Clone(entities), func(e vmomi.Entity) bool {
+		return !slices.Contains(ids, e.ID) && !slices.Contains(names, e.Name)
+	})
+
+	for _, key := range slices.Concat(ids, names) {
+		if !slices.ContainsFunc(selected, func(e vmomi.Entity) bool {
+			return e.ID == key || e.Name == key
+		}) {
+			return nil, fmt.Errorf("entity not found %v", key)
+		}
+	}
+
+	return selected, nil
+}
+
+func getSeriesCounters(cmd *cobra.Command) ([]vmomi.CounterInfo, error) {
+	values, err := cmd.Flags().GetStringSlice("counter")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == empty {
+		return nil, errors.New("counter is required")
+	}
+
+	counters := []vmomi.CounterInfo{}
+	for _, v := range values {
+		c, err := vmomi.ParseCounter(v)
+		if err != nil {
+			return nil, err
+		}
+
+		counters = append(counters, *c)
+	}
+
+	return counters, nil
+}
+
+func setSeriesPeriod(cmd *cobra.Command, query *vmomi.SeriesQuery) error {
+	var err error
+	if query.Start, err = getTime(cmd, "start"); err != nil {
+		return err
+	}
+
+	if query.End, err = getTime(cmd, "end"); err != nil {
+		return err
+	}
+
+	if query.Lookback, err = cmd.Flags().GetDuration("last"); err != nil {
+		return err
+	}
+
+	if query.Start != nil && query.Lookback != time.Duration(empty) {
+		return errors.New("start and last are exclusive")
+	}
+
+	return nil
+}
+
+func getTime(cmd *cobra.Command, name string) (*time.Time, error) {
+	value, err := cmd.Flags().GetString(name)
+	if err != nil || value == noValue {
+		return nil, err
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v: %w", name, err)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+func selectSubtree(cmd *cobra.Command, root *vmomi.EntityNode) ([]*vmomi.EntityNode, error) {
+	if root == nil {
+		return nil, errors.New("not found root folder")
+	}
+
+	entityType, err := cmd.Flags().GetString("entity-type")
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := cmd.Flags().GetString("entity-id")
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := cmd.Flags().GetString("entity-name")
+	if err != nil {
+		return nil, err
+	}
+
+	if entityType == noValue && id == noValue && name == noValue {
+		return []*vmomi.EntityNode{root}, nil
+	}
+
+	nodes := root.Find(func(e vmomi.Entity) bool {
+		return matchEntity(e, entityType, id, name)
+	})
+	if len(nodes) == empty {
+		return nil, errors.New("not found subtree root")
+	}
+
+	return nodes, nil
+}
+
+func matchEntity(e vmomi.Entity, entityType, id, name string) bool {
+	return (entityType == noValue || string(e.Type) == entityType) &&
+		(id == noValue || e.ID == id) &&
+		(name == noValue || e.Name == name)
+}
@@ -0,0 +1,108 @@
+// Package protowiretest decodes protobuf wire format for tests without generated types.
+package protowiretest
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const first = 0
+
+type Message struct {
+	tb     testing.TB
+	values map[protowire.Number][]value
+}
+
+type value struct {
+	bytes  []byte
+	number uint64
+}
+
+// Decode parses fields of b, and fails the test if b is malformed.
+func Decode(tb testing.TB, b []byte) Message {
+	tb.Helper()
+
+	m := Message{tb: tb, values: map[protowire.Number][]value{}}
+	for len(b) > first {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < first {
+			tb.Fatalf("Consume tag: %v", protowire.ParseError(n))
+		}
+
+		b = b[n:]
+
+		v, n := consumeValue(typ, b)
+		if n < first {
+			tb.Fatalf("Consume field %v: %v", num, protowire.ParseError(n))
+		}
+
+		b = b[n:]
+		m.values[num] = append(m.values[num], v)
+	}
+
+	return m
+}
+
+func consumeValue(typ protowire.Type, b []byte) (value, int) {
+	switch typ {
+	case protowire.BytesType:
+		v, n := protowire.ConsumeBytes(b)
+		return value{bytes: v}, n
+	case protowire.Fixed64Type:
+		v, n := protowire.ConsumeFixed64(b)
+		return value{number: v}, n
+	case protowire.Fixed32Type:
+		v, n := protowire.ConsumeFixed32(b)
+		return value{number: uint64(v)}, n
+	case protowire.VarintType:
+		v, n := protowire.ConsumeVarint(b)
+		return value{number: v}, n
+	default:
+		return value{}, protowire.ConsumeFieldValue(protowire.Number(first), typ, b)
+	}
+}
+
+// Messages decodes all values of field num as messages.
+func (m Message) Messages(num protowire.Number) []Message {
+	m.tb.Helper()
+
+	messages := []Message{}
+	for _, v := range m.values[num] {
+		messages = append(messages, Decode(m.tb, v.bytes))
+	}
+
+	return messages
+}
+
+// Message decodes the first value of field num as message.
+func (m Message) Message(num protowire.Number) Message {
+	m.tb.Helper()
+
+	return Decode(m.tb, m.first(num).bytes)
+}
+
+// String returns the first value of field num as string.
+func (m Message) String(num protowire.Number) string {
+	m.tb.Helper()
+
+	return string(m.first(num).bytes)
+}
+
+// Uint64 returns the first value of field num as fixed or varint number.
+func (m Message) Uint64(num protowire.Number) uint64 {
+	m.tb.Helper()
+
+	return m.first(num).number
+}
+
+func (m Message) first(num protowire.Number) value {
+	m.tb.Helper()
+
+	values := m.values[num]
+	if len(values) == first {
+		m.tb.Fatalf("Not found field %v", num)
+	}
+
+	return values[first]
+}
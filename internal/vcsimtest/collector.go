@@ -0,0 +1,77 @@
+package vcsimtest
+
+import (
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// propertyCollector resolves selection specs by name in the whole filter spec as vCenter does.
+// vcsim resolves a selection spec name only in the traversal specs already traversed,
+// so it rejects the nested traversal specs which refer a spec in the sibling.
+type propertyCollector struct {
+	simulator.PropertyCollector
+}
+
+func (pc *propertyCollector) RetrieveProperties(
+	ctx *simulator.Context,
+	r *types.RetrieveProperties,
+) soap.HasFault {
+	resolveSpecSet(r.SpecSet)
+	return pc.PropertyCollector.RetrieveProperties(ctx, r)
+}
+
+func (pc *propertyCollector) RetrievePropertiesEx(
+	ctx *simulator.Context,
+	r *types.RetrievePropertiesEx,
+) soap.HasFault {
+	resolveSpecSet(r.SpecSet)
+	return pc.PropertyCollector.RetrievePropertiesEx(ctx, r)
+}
+
+func wrapPropertyCollector(model *simulator.Model) {
+	pc := new(propertyCollector)
+	pc.Self = model.ServiceContent.PropertyCollector
+	model.Map().Put(pc)
+}
+
+func resolveSpecSet(specSet []types.PropertyFilterSpec) {
+	for _, spec := range specSet {
+		defined := map[string]*types.TraversalSpec{}
+		for _, o := range spec.ObjectSet {
+			defineSpecs(o.SelectSet, defined)
+		}
+
+		for _, o := range spec.ObjectSet {
+			resolveSpecs(o.SelectSet, defined)
+		}
+	}
+}
+
+func defineSpecs(selectSet []types.BaseSelectionSpec, defined map[string]*types.TraversalSpec) {
+	for _, s := range selectSet {
+		if ts, ok := s.(*types.TraversalSpec); ok {
+			if _, found := defined[ts.Name]; !found {
+				defined[ts.Name] = ts
+			}
+
+			defineSpecs(ts.SelectSet, defined)
+		}
+	}
+}
+
+// resolveSpecs replaces a reference by name with the traversal spec.
+// It walks only the requested tree, so the resolved cycles are never walked.
+func resolveSpecs(selectSet []types.BaseSelectionSpec, defined map[string]*types.TraversalSpec) {
+	for i, s := range selectSet {
+		switch spec := s.(type) {
+		case *types.TraversalSpec:
+			resolveSpecs(spec.SelectSet, defined)
+		case *types.SelectionSpec:
+			if ts, found := defined[spec.Name]; found {
+				selectSet[i] = ts
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package vcsimtest starts the govmomi simulator with a generated inventory for tests.
+package vcsimtest
+
+import (
+	"bytes"
+	"context"
+	goflag "flag"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+)
+
+const (
+	defaultCluster     = 1
+	defaultClusterHost = 2
+	defaultDatacenter  = 1
+	defaultHost        = 1
+	defaultMachine     = 1
+	goldenDirPerm      = 0o755
+	goldenFilePerm     = 0o644
+	unset              = ""
+)
+
+var update = goflag.Bool("update", false, "Update golden files.")
+
+type Options struct {
+	Datacenter  int
+	Cluster     int
+	ClusterHost int
+	Host        int
+	Machine     int
+}
+
+type Simulator struct {
+	Context context.Context
+	Model   *simulator.Model
+	URL     *url.URL
+}
+
+func defaultOptions() Options {
+	return Options{
+		Datacenter:  defaultDatacenter,
+		Cluster:     defaultCluster,
+		ClusterHost: defaultClusterHost,
+		Host:        defaultHost,
+		Machine:     defaultMachine,
+	}
+}
+
+func WithDatacenter(n int) func(o *Options) {
+	return func(o *Options) {
+		o.Datacenter = n
+	}
+}
+
+func WithCluster(n int, hosts int) func(o *Options) {
+	return func(o *Options) {
+		o.Cluster = n
+		o.ClusterHost = hosts
+	}
+}
+
+func WithHost(n int) func(o *Options) {
+	return func(o *Options) {
+		o.Host = n
+	}
+}
+
+func WithMachine(n int) func(o *Options) {
+	return func(o *Options) {
+		o.Machine = n
+	}
+}
+
+// Start runs a vCenter simulator until the test finishes,
+// and returns a context to connect to it as command line flags do.
+func Start(tb testing.TB, opts ...func(o *Options)) *Simulator {
+	tb.Helper()
+
+	opt := defaultOptions()
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	model := simulator.VPX()
+	model.Datacenter = opt.Datacenter
+	model.Cluster = opt.Cluster
+	model.ClusterHost = opt.ClusterHost
+	model.Host = opt.Host
+	model.Machine = opt.Machine
+
+	if err := model.Create(); err != nil {
+		tb.Fatalf("Create simulator: %v", err)
+	}
+
+	wrapPropertyCollector(model)
+
+	server := model.Service.NewServer()
+	tb.Cleanup(func() {
+		server.Close()
+		model.Remove()
+	})
+
+	return &Simulator{
+		Context: WithTarget(tb.Context(), server.URL),
+		Model:   model,
+		URL:     server.URL,
+	}
+}
+
+func WithTarget(ctx context.Context, u *url.URL) context.Context {
+	password, _ := u.User.Password()
+
+	target := *u
+	target.User = nil
+
+	values := map[any]any{
+		flag.TargetURLKey{}:           target.String(),
+		flag.TargetUserKey{}:          u.User.Username(),
+		flag.TargetPasswordKey{}:      password,
+		flag.TargetPasswordFileKey{}:  unset,
+		flag.TargetNoVerifySSLKey{}:   true,
+		flag.TargetCAFileKey{}:        unset,
+		flag.TargetThumbprintKey{}:    unset,
+		flag.TargetTLSServerNameKey{}: unset,
+	}
+
+	for k, v := range values {
+		ctx = context.WithValue(ctx, k, v)
+	}
+
+	return ctx
+}
+
+// AssertGolden compares actual with the golden file, and rewrites it with `-update` flag.
+func AssertGolden(tb testing.TB, path string, actual []byte) {
+	tb.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), goldenDirPerm); err != nil {
+			tb.Fatalf("Create golden directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, actual, goldenFilePerm); err != nil {
+			tb.Fatalf("Write golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("Read golden file: %v", err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		tb.Errorf(
+			"Not match golden file %v\n--- expected\n%s\n--- actual\n%s",
+			path,
+			expected,
+			actual,
+		)
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"go.yaml.in/yaml/v4"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+type Aggregate struct {
+	Type      vmomi.ManagedEntityType `yaml:"type"`
+	Functions []string                `yaml:"functions,omitempty"`
+}
+
+type AggregateConfig struct {
+	Aggregates []Aggregate `yaml:"aggregates,omitempty"`
+}
+
+func EncodeAggregates(a *[]Aggregate) (string, error) {
+	cc := AggregateConfig{
+		Aggregates: *a,
+	}
+
+	buf, err := yaml.Marshal(&cc)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func DefaultAggregateConfig() *AggregateConfig {
+	return &AggregateConfig{
+		Aggregates: []Aggregate{},
+	}
+}
+
+func (a *Aggregate) ToAggregateSpec() (*vmomi.AggregateSpec, error) {
+	spec := vmomi.AggregateSpec{
+		Type:      a.Type,
+		Functions: a.Functions,
+	}
+
+	if err := vmomi.ValidateAggregate(spec); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
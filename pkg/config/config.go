@@ -8,11 +8,24 @@ import (
 	"go.yaml.in/yaml/v4"
 )
 
+const (
+	empty       = 0
+	noValue     = ""
+	countersKey = "counters"
+	mappingStep = 2
+	valueOffset = 1
+)
+
 type Config struct {
-	CounterConfig  `yaml:",omitempty,inline"`
-	ObjectConfig   `yaml:",omitempty,inline"`
-	RootConfig     `yaml:",omitempty,inline"`
-	RetrieveConfig `yaml:"retrieve,omitempty"`
+	CounterConfig    `yaml:",omitempty,inline"`
+	ObjectConfig     `yaml:",omitempty,inline"`
+	RootConfig       `yaml:",omitempty,inline"`
+	ExcludeConfig    `yaml:",omitempty,inline"`
+	RetrieveConfig   `yaml:"retrieve,omitempty"`
+	CredentialConfig `yaml:",omitempty,inline"`
+	TransportConfig  `yaml:"transport,omitempty"`
+	AggregateConfig  `yaml:",omitempty,inline"`
+	PushConfig       `yaml:"push,omitempty"`
 }
 
 func DecodeConfig(config []byte) (*Config, error) {
@@ -22,6 +35,10 @@ func DecodeConfig(config []byte) (*Config, error) {
 		return nil, err
 	}
 
+	if err := validateRoots(c.Roots, c.Excludes); err != nil {
+		return nil, err
+	}
+
 	return &c, nil
 }
 
@@ -34,12 +51,55 @@ func EncodeConfig(c *Config) (string, error) {
 	return string(buf), nil
 }
 
+// EncodeCommentedConfig encodes config with head comment and comment per counter.
+func EncodeCommentedConfig(c *Config, head string, comments map[Counter]string) (string, error) {
+	var node yaml.Node
+	if err := node.Encode(c); err != nil {
+		return noValue, err
+	}
+
+	node.HeadComment = head
+	commentCounters(&node, comments)
+
+	buf, err := yaml.Marshal(&node)
+	return string(buf), err
+}
+
+func commentCounters(node *yaml.Node, comments map[Counter]string) {
+	counters := findMappingValue(node, countersKey)
+	if counters == nil {
+		return
+	}
+
+	for _, item := range counters.Content {
+		var c Counter
+		if err := item.Decode(&c); err == nil {
+			item.HeadComment = comments[c]
+		}
+	}
+}
+
+func findMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for idx := empty; idx+valueOffset < len(node.Content); idx += mappingStep {
+		if node.Content[idx].Value == key {
+			return node.Content[idx+valueOffset]
+		}
+	}
+
+	return nil
+}
+
 func DefaultConfig() *Config {
 	return &Config{
-		CounterConfig:  *DefaultCounterConfig(),
-		ObjectConfig:   *DefaultObjectConfig(),
-		RootConfig:     *DefaultRootConfig(),
-		RetrieveConfig: *DefaultRetrieveConfig(),
+		CounterConfig:    *DefaultCounterConfig(),
+		ObjectConfig:     *DefaultObjectConfig(),
+		RootConfig:       *DefaultRootConfig(),
+		ExcludeConfig:    *DefaultExcludeConfig(),
+		RetrieveConfig:   *DefaultRetrieveConfig(),
+		CredentialConfig: CredentialConfig{},
+		TransportConfig:  *DefaultTransportConfig(),
+		AggregateConfig:  *DefaultAggregateConfig(),
+		PushConfig:       *DefaultPushConfig(),
 	}
 }
 
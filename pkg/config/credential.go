@@ -0,0 +1,63 @@
+package config
+
+import (
+	"go.yaml.in/yaml/v4"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+type Credential struct {
+	URL             string   `yaml:"url"`
+	User            string   `yaml:"user"`
+	Password        string   `yaml:"password,omitempty"`
+	PasswordFile    string   `yaml:"password_file,omitempty"`
+	PasswordCommand []string `yaml:"password_command,omitempty"`
+}
+
+type CredentialConfig struct {
+	Credentials []Credential `yaml:"credentials,omitempty"`
+}
+
+func EncodeCredentials(c *[]Credential) (string, error) {
+	cc := CredentialConfig{
+		Credentials: *c,
+	}
+
+	buf, err := yaml.Marshal(&cc)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func (c *CredentialConfig) FindCredential(url string) *Credential {
+	for _, cred := range c.Credentials {
+		if cred.URL == url {
+			return &cred
+		}
+	}
+
+	return nil
+}
+
+func (c *Credential) Provider() vmomi.CredentialProvider {
+	if len(c.PasswordCommand) != empty {
+		return &vmomi.ExecCredentialProvider{
+			User:    c.User,
+			Command: c.PasswordCommand,
+		}
+	}
+
+	if c.PasswordFile != "" {
+		return &vmomi.FileCredentialProvider{
+			User:         c.User,
+			PasswordFile: c.PasswordFile,
+		}
+	}
+
+	return &vmomi.StaticCredentialProvider{
+		User:     c.User,
+		Password: c.Password,
+	}
+}
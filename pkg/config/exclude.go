@@ -0,0 +1,11 @@
+package config
+
+type ExcludeConfig struct {
+	Excludes []Root `yaml:"excludes,omitempty"`
+}
+
+func DefaultExcludeConfig() *ExcludeConfig {
+	return &ExcludeConfig{
+		Excludes: []Root{},
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"go.yaml.in/yaml/v4"
 
 	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
@@ -12,7 +14,9 @@ var defaultTypes = []vmomi.ManagedEntityType{
 }
 
 type Object struct {
-	Type *vmomi.ManagedEntityType `yaml:"type,omitempty"`
+	Type     *vmomi.ManagedEntityType `yaml:"type,omitempty"`
+	Interval string                   `yaml:"interval,omitempty"`
+	Lookback time.Duration            `yaml:"lookback,omitempty"`
 }
 
 type ObjectConfig struct {
@@ -44,3 +48,15 @@ func DefaultObjectConfig() *ObjectConfig {
 		Objects: objects,
 	}
 }
+
+func (o *Object) ToQueryObject() (*vmomi.QueryObject, error) {
+	if err := vmomi.ValidateInterval(o.Interval); err != nil {
+		return nil, err
+	}
+
+	return &vmomi.QueryObject{
+		Type:     *o.Type,
+		Interval: o.Interval,
+		Lookback: o.Lookback,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"time"
+
+	"go.yaml.in/yaml/v4"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/otlp"
+	"github.com/9506hqwy/vmomi-exporter/pkg/remotewrite"
+	"github.com/9506hqwy/vmomi-exporter/pkg/sink"
+)
+
+const unset = ""
+
+type OTLPConfig struct {
+	Endpoint string            `yaml:"endpoint"`
+	Protocol string            `yaml:"protocol,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	Timeout  time.Duration     `yaml:"timeout,omitempty"`
+}
+
+type RemoteWriteConfig struct {
+	URL        string            `yaml:"url"`
+	Headers    map[string]string `yaml:"headers,omitempty"`
+	Timeout    time.Duration     `yaml:"timeout,omitempty"`
+	BatchSize  int               `yaml:"batch_size,omitempty"`
+	MaxRetries *int              `yaml:"max_retries,omitempty"`
+}
+
+type InfluxDBConfig struct {
+	URL            string            `yaml:"url"`
+	Headers        map[string]string `yaml:"headers,omitempty"`
+	Timeout        time.Duration     `yaml:"timeout,omitempty"`
+	MaxPayloadSize int               `yaml:"max_payload_size,omitempty"`
+}
+
+type GraphiteConfig struct {
+	Address string        `yaml:"address"`
+	Prefix  string        `yaml:"prefix,omitempty"`
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+type PushConfig struct {
+	Interval    time.Duration      `yaml:"interval,omitempty"`
+	OTLP        *OTLPConfig        `yaml:"otlp,omitempty"`
+	RemoteWrite *RemoteWriteConfig `yaml:"remote_write,omitempty"`
+	InfluxDB    *InfluxDBConfig    `yaml:"influxdb,omitempty"`
+	Graphite    *GraphiteConfig    `yaml:"graphite,omitempty"`
+}
+
+func EncodePushConfig(c *PushConfig) (string, error) {
+	buf, err := yaml.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func DefaultPushConfig() *PushConfig {
+	return &PushConfig{
+		Interval:    empty,
+		OTLP:        nil,
+		RemoteWrite: nil,
+		InfluxDB:    nil,
+		Graphite:    nil,
+	}
+}
+
+func (c *OTLPConfig) ToExporter(serverAddress string) (*otlp.Exporter, error) {
+	opts := []func(o *otlp.ExporterOptions){
+		otlp.WithHeaders(c.Headers),
+		otlp.WithServerAddress(serverAddress),
+	}
+
+	if c.Protocol != unset {
+		opts = append(opts, otlp.WithProtocol(c.Protocol))
+	}
+
+	if c.Timeout != empty {
+		opts = append(opts, otlp.WithTimeout(c.Timeout))
+	}
+
+	return otlp.NewExporter(c.Endpoint, opts...)
+}
+
+func (c *RemoteWriteConfig) ToClient() *remotewrite.Client {
+	opts := []func(o *remotewrite.ClientOptions){
+		remotewrite.WithHeaders(c.Headers),
+	}
+
+	if c.Timeout != empty {
+		opts = append(opts, remotewrite.WithTimeout(c.Timeout))
+	}
+
+	if c.BatchSize != empty {
+		opts = append(opts, remotewrite.WithBatchSize(c.BatchSize))
+	}
+
+	if c.MaxRetries != nil {
+		opts = append(opts, remotewrite.WithMaxRetries(*c.MaxRetries))
+	}
+
+	return remotewrite.NewClient(c.URL, opts...)
+}
+
+func (c *InfluxDBConfig) ToSink() (*sink.InfluxDB, error) {
+	opts := []func(o *sink.InfluxDBOptions){
+		sink.WithInfluxDBHeaders(c.Headers),
+	}
+
+	if c.Timeout != empty {
+		opts = append(opts, sink.WithInfluxDBTimeout(c.Timeout))
+	}
+
+	if c.MaxPayloadSize != empty {
+		opts = append(opts, sink.WithMaxPayloadSize(c.MaxPayloadSize))
+	}
+
+	return sink.NewInfluxDB(c.URL, opts...)
+}
+
+func (c *GraphiteConfig) ToSink() *sink.Graphite {
+	opts := []func(o *sink.GraphiteOptions){}
+
+	if c.Prefix != unset {
+		opts = append(opts, sink.WithPrefix(c.Prefix))
+	}
+
+	if c.Timeout != empty {
+		opts = append(opts, sink.WithGraphiteTimeout(c.Timeout))
+	}
+
+	return sink.NewGraphite(c.Address, opts...)
+}
@@ -7,8 +7,11 @@ import (
 )
 
 type Root struct {
-	Type vmomi.ManagedEntityType `yaml:"type"`
-	Name string                  `yaml:"name"`
+	Type    vmomi.ManagedEntityType `yaml:"type"`
+	Name    string                  `yaml:"name"`
+	Pattern string                  `yaml:"pattern,omitempty"`
+	Path    string                  `yaml:"path,omitempty"`
+	ID      string                  `yaml:"id,omitempty"`
 }
 
 type RootConfig struct {
@@ -31,10 +34,42 @@ func EncodeRoots(r *[]Root) (string, error) {
 func DefaultRootConfig() *RootConfig {
 	roots := []Root{{
 		Type: vmomi.ManagedEntityTypeFolder,
-		Name: "",
+		Name: noValue,
 	}}
 
 	return &RootConfig{
 		Roots: roots,
 	}
 }
+
+// IsRootFolder reports whether root selects all entities from root folder.
+func (r *Root) IsRootFolder() bool {
+	return r.Type == vmomi.ManagedEntityTypeFolder &&
+		r.Name == noValue &&
+		r.Pattern == noValue &&
+		r.Path == noValue &&
+		r.ID == noValue
+}
+
+func (r *Root) ToRootSpec() vmomi.RootSpec {
+	return vmomi.RootSpec{
+		Type:    r.Type,
+		Name:    r.Name,
+		Pattern: r.Pattern,
+		Path:    r.Path,
+		ID:      r.ID,
+	}
+}
+
+// validateRoots reports invalid pattern at loading config instead of every scrape.
+func validateRoots(roots ...[]Root) error {
+	for _, rs := range roots {
+		for _, r := range rs {
+			if err := vmomi.ValidateRoot(r.ToRootSpec()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
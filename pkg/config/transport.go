@@ -0,0 +1,46 @@
+package config
+
+import (
+	"time"
+
+	"go.yaml.in/yaml/v4"
+
+	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
+)
+
+type TransportConfig struct {
+	ProxyURL            string        `yaml:"proxy_url,omitempty"`
+	NoProxy             string        `yaml:"no_proxy,omitempty"`
+	MaxIdleConns        int           `yaml:"max_idle_conns,omitempty"`
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host,omitempty"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout,omitempty"`
+	DisableKeepAlives   bool          `yaml:"disable_keep_alives,omitempty"`
+	UserAgent           string        `yaml:"user_agent,omitempty"`
+	HTTP2               bool          `yaml:"http2,omitempty"`
+}
+
+func EncodeTransportConfig(c *TransportConfig) (string, error) {
+	buf, err := yaml.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func DefaultTransportConfig() *TransportConfig {
+	return &TransportConfig{}
+}
+
+func (c *TransportConfig) ToTransportOptions() *sx.TransportOptions {
+	return &sx.TransportOptions{
+		ProxyURL:            c.ProxyURL,
+		NoProxy:             c.NoProxy,
+		MaxIdleConns:        c.MaxIdleConns,
+		MaxIdleConnsPerHost: c.MaxIdleConnsPerHost,
+		IdleConnTimeout:     c.IdleConnTimeout,
+		DisableKeepAlives:   c.DisableKeepAlives,
+		UserAgent:           c.UserAgent,
+		HTTP2:               c.HTTP2,
+	}
+}
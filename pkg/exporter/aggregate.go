@@ -0,0 +1,54 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	LabelAggregateFunction = "aggregate_function"
+	LabelAggregateType     = "aggregate_type"
+)
+
+const aggregateSuffix = "_aggregate"
+
+func ToAggregateGaugeID(c *vmomi.CounterInfo) string {
+	return ToPerfGaugeID(c) + aggregateSuffix
+}
+
+func sendAggregateMetric(ch chan<- prometheus.Metric, a vmomi.AggregateMetric) {
+	desc := prometheus.NewDesc(
+		ToAggregateGaugeID(&a.Counter),
+		a.Counter.NameSummary,
+		[]string{
+			LabelCounterInterval,
+			LabelEntityID,
+			LabelEntityName,
+			LabelEntityType,
+			LabelAggregateType,
+			LabelAggregateFunction,
+		},
+		prometheus.Labels{
+			LabelCounterID:   fmt.Sprintf("%v", a.Counter.ID),
+			LabelCounterStat: a.Counter.Stats,
+			LabelCounterUnit: a.Counter.Unit,
+		},
+	)
+
+	metric := prometheus.MustNewConstMetric(
+		desc,
+		prometheus.GaugeValue,
+		a.Value,
+		fmt.Sprintf("%v", a.Interval),
+		a.Entity.ID,
+		a.Entity.Name,
+		string(a.Entity.Type),
+		string(a.SourceType),
+		a.Function,
+	)
+
+	ch <- prometheus.NewMetricWithTimestamp(a.Timestamp, metric)
+}
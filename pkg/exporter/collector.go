@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -15,11 +16,13 @@ import (
 
 type VmomiCollectorOptions struct {
 	Context context.Context
+	Health  *Health
 }
 
 type vmomiCollector struct {
 	Context    context.Context
 	Config     config.Config
+	health     *Health
 	metrics    []PerfGauge
 	metricRock sync.RWMutex
 }
@@ -27,6 +30,7 @@ type vmomiCollector struct {
 func defaultGoCollectorOptions() VmomiCollectorOptions {
 	return VmomiCollectorOptions{
 		Context: nil,
+		Health:  nil,
 	}
 }
 
@@ -36,12 +40,38 @@ func WithVmomiCollectorContext(ctx context.Context) func(o *VmomiCollectorOption
 	}
 }
 
+func WithVmomiCollectorHealth(h *Health) func(o *VmomiCollectorOptions) {
+	return func(o *VmomiCollectorOptions) {
+		o.Health = h
+	}
+}
+
 func NewVmomiCollector(opts ...func(o *VmomiCollectorOptions)) prometheus.Collector {
+	return createVmomiCollector(opts...)
+}
+
+func createVmomiCollector(opts ...func(o *VmomiCollectorOptions)) *vmomiCollector {
+	c := buildVmomiCollector(opts...)
+
+	// Discover counters in background to start without vSphere server.
+	go c.discover()
+
+	// Push metrics in background if configured.
+	go c.push()
+
+	return c
+}
+
+func buildVmomiCollector(opts ...func(o *VmomiCollectorOptions)) *vmomiCollector {
 	opt := defaultGoCollectorOptions()
 	for _, o := range opts {
 		o(&opt)
 	}
 
+	if opt.Health == nil {
+		opt.Health = NewHealth()
+	}
+
 	infoStartedLog(opt.Context)
 
 	cfg, err := config.GetConfig(opt.Context)
@@ -50,12 +80,6 @@ func NewVmomiCollector(opts ...func(o *VmomiCollectorOptions)) prometheus.Collec
 		cfg = config.DefaultConfig()
 	}
 
-	metrics, err := GetPerfGauge(opt.Context)
-	if err != nil {
-		errorCompletedLog(opt.Context, err)
-		panic(err)
-	}
-
 	opt.Context = context.WithValue(
 		opt.Context,
 		propertyex.IgnoreDatastoreVMKey{},
@@ -68,71 +92,94 @@ func NewVmomiCollector(opts ...func(o *VmomiCollectorOptions)) prometheus.Collec
 		cfg.IgnoreNetworkVM,
 	)
 
-	infoCompletedLog(opt.Context, "metric_count", len(metrics))
-	return &vmomiCollector{
+	c := &vmomiCollector{
 		Context: opt.Context,
 		Config:  *cfg,
-		metrics: metrics,
+		health:  opt.Health,
+		metrics: nil,
 	}
+
+	infoCompletedLog(opt.Context)
+	return c
+}
+
+func (*vmomiCollector) Describe(_ chan<- *prometheus.Desc) {
+	// Unchecked collector because counters are discovered after registration.
 }
 
-func (c *vmomiCollector) Describe(ch chan<- *prometheus.Desc) {
-	slog.InfoContext(c.Context, "Started")
+func (c *vmomiCollector) Collect(ch chan<- prometheus.Metric) {
+	// Error is exported as vmomi_exporter_scrape_success.
+	//revive:disable-next-line:unhandled-error
+	c.collectContext(c.Context, ch)
+}
 
-	c.metricRock.RLock()
-	defer c.metricRock.RUnlock()
+func (c *vmomiCollector) collectContext(ctx context.Context, ch chan<- prometheus.Metric) error {
+	infoStartedLog(ctx)
 
-	for _, m := range c.metrics {
-		m.Gauge.Describe(ch)
-	}
+	started := time.Now()
 
-	infoCompletedLog(c.Context)
-}
+	result, err := c.collectVmomi(ctx, ch)
 
-func (c *vmomiCollector) Collect(ch chan<- prometheus.Metric) {
-	infoStartedLog(c.Context)
+	sendScrapeMetrics(ch, time.Since(started), result, err)
 
-	roots, err := ToEntityFromRoot(c.Context, c.Config.Roots)
 	if err != nil {
-		errorCompletedLog(c.Context, err)
-		return
+		errorCompletedLog(ctx, err)
+		return err
 	}
 
-	moTypes := []string{}
-	for _, o := range c.Config.Objects {
-		moTypes = append(moTypes, string(*o.Type))
+	infoCompletedLog(ctx)
+	return nil
+}
+
+func (c *vmomiCollector) collectVmomi(
+	ctx context.Context,
+	ch chan<- prometheus.Metric,
+) (*vmomi.QueryResult, error) {
+	if !c.discovered() {
+		return nil, errDiscoveryPending
 	}
 
-	counters := []vmomi.CounterInfo{}
-	for _, o := range c.Config.Counters {
-		v := vmomi.CounterInfo{
-			Group:  o.Group,
-			Name:   o.Name,
-			Rollup: o.Rollup,
-		}
-		counters = append(counters, v)
+	roots, err := ToEntityFromRoot(ctx, c.Config.Roots)
+	c.health.SetLogin(toLoginResult(err))
+	if err != nil {
+		return nil, err
 	}
 
-	metrics, err := vmomi.Query(c.Context, roots, moTypes, counters)
+	result, err := queryVmomi(ctx, &c.Config, roots)
+	c.health.SetLogin(toLoginResult(err))
 	if err != nil {
-		errorCompletedLog(c.Context, err)
-		return
+		return nil, err
+	}
+
+	if result.FailedChunks != empty {
+		slog.WarnContext(
+			ctx,
+			"Partial results",
+			"failed_chunks", result.FailedChunks,
+			"failed_entities", result.FailedEntities,
+		)
 	}
 
 	c.metricRock.Lock()
 	defer c.metricRock.Unlock()
 
+	defer vmomi.ObservePhase(ctx, vmomi.PhaseConversion, time.Now())
+
 	// Reset all metrics to remove all instances.
 	resetMetrics(c.metrics)
 
 	// Do not use because expose metrics with timestamp
 	// gauge.Gauge.Collect(ch)
 
-	for _, m := range metrics {
+	for _, m := range result.Metrics {
 		c.sendMetric(ch, m)
 	}
 
-	infoCompletedLog(c.Context)
+	for _, a := range result.Aggregates {
+		sendAggregateMetric(ch, a)
+	}
+
+	return result, nil
 }
 
 func (c *vmomiCollector) sendMetric(ch chan<- prometheus.Metric, m vmomi.Metric) {
@@ -187,3 +234,70 @@ func infoCompletedLog(c context.Context, args ...any) {
 func errorCompletedLog(c context.Context, err error) {
 	slog.ErrorContext(c, "Completed", "error", err)
 }
+
+func toQueryObjects(objects []config.Object) ([]vmomi.QueryObject, error) {
+	queryObjects := []vmomi.QueryObject{}
+	for _, o := range objects {
+		q, err := o.ToQueryObject()
+		if err != nil {
+			return nil, err
+		}
+
+		queryObjects = append(queryObjects, *q)
+	}
+
+	return queryObjects, nil
+}
+
+func toAggregateSpecs(aggregates []config.Aggregate) ([]vmomi.AggregateSpec, error) {
+	specs := []vmomi.AggregateSpec{}
+	for _, a := range aggregates {
+		spec, err := a.ToAggregateSpec()
+		if err != nil {
+			return nil, err
+		}
+
+		specs = append(specs, *spec)
+	}
+
+	return specs, nil
+}
+
+func queryVmomi(
+	ctx context.Context,
+	cfg *config.Config,
+	roots *[]vmomi.Entity,
+) (*vmomi.QueryResult, error) {
+	objects, err := toQueryObjects(cfg.Objects)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregates, err := toAggregateSpecs(cfg.Aggregates)
+	if err != nil {
+		return nil, err
+	}
+
+	return vmomi.Query(
+		ctx,
+		roots,
+		objects,
+		toCounterInfos(cfg.Counters),
+		vmomi.WithAggregates(aggregates),
+		vmomi.WithExcludes(toRootSpecs(cfg.Excludes)),
+	)
+}
+
+func toCounterInfos(cnts []config.Counter) []vmomi.CounterInfo {
+	counters := []vmomi.CounterInfo{}
+	for _, o := range cnts {
+		v := vmomi.CounterInfo{
+			Group:  o.Group,
+			Name:   o.Name,
+			Rollup: o.Rollup,
+		}
+		counters = append(counters, v)
+	}
+
+	return counters
+}
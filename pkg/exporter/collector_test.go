@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+)
+
+const discoveryTimeout = 10 * time.Second
+
+// Mask values and timestamps because vcsim generates random samples at current time.
+var sampleValue = regexp.MustCompile(`(?m)^([^#\s]+) \S+( \d+)?$`)
+
+func startCollector(t *testing.T, configPath string) *vmomiCollector {
+	t.Helper()
+
+	sim := vcsimtest.Start(t)
+	ctx := context.WithValue(sim.Context, flag.ExporterConfigKey{}, configPath)
+
+	c := createVmomiCollector(WithVmomiCollectorContext(ctx))
+
+	deadline := time.Now().Add(discoveryTimeout)
+	for !c.discovered() {
+		if time.Now().After(deadline) {
+			t.Fatal("Discovery timeout")
+		}
+
+		time.Sleep(discoveryInitialBackoff / 10)
+	}
+
+	return c
+}
+
+func scrape(t *testing.T, c *vmomiCollector) string {
+	t.Helper()
+
+	server := httptest.NewServer(metricsHandler(prometheus.NewRegistry(), c))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	c := startCollector(t, "testdata/config.yaml")
+
+	body := scrape(t, c)
+	if !strings.Contains(body, "\nvmomi_exporter_scrape_success 1\n") {
+		t.Errorf("Scrape failed\n%v", body)
+	}
+
+	masked := sampleValue.ReplaceAllString(body, "$1 <value>")
+	vcsimtest.AssertGolden(t, "testdata/metrics.golden", []byte(masked))
+}
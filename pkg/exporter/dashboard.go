@@ -0,0 +1,191 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/config"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	dashboardTitle   = "VMOMI Exporter"
+	dashboardUID     = "vmomi-exporter"
+	dashboardSchema  = 39
+	datasourceVar    = "${datasource}"
+	datasourceType   = "prometheus"
+	panelColumns     = 2
+	panelHeight      = 8
+	panelWidth       = 12
+	rowHeight        = 1
+	roundUp          = panelColumns - 1
+	firstPanelID     = 1
+	perfLegend       = "{{entity_name}} {{entity_instance}}"
+	aggregateLegend  = "{{entity_name}} {{aggregate_type}} {{aggregate_function}}"
+	aggregateRowName = "%v aggregate"
+)
+
+type Dashboard struct {
+	Title         string     `json:"title"`
+	UID           string     `json:"uid"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Time          timeRange  `json:"time"`
+	Templating    templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+type timeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type templating struct {
+	List []variable `json:"list"`
+}
+
+type variable struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+type Panel struct {
+	ID          int         `json:"id"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title"`
+	Description string      `json:"description,omitempty"`
+	GridPos     gridPos     `json:"gridPos"`
+	Datasource  *datasource `json:"datasource,omitempty"`
+	Targets     []target    `json:"targets,omitempty"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type target struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+type panelQuery struct {
+	title  string
+	metric string
+	legend string
+}
+
+type dashboardLayout struct {
+	panels []Panel
+	y      int
+}
+
+// NewDashboard creates Grafana dashboard which has a row per object type
+// and a panel per counter in the row.
+func NewDashboard(cfg *config.Config) *Dashboard {
+	layout := dashboardLayout{panels: []Panel{}, y: empty}
+
+	for _, o := range cfg.Objects {
+		if o.Type == nil {
+			continue
+		}
+
+		layout.addRow(string(*o.Type), *o.Type, perfQueries(cfg.Counters, ToPerfGaugeID))
+	}
+
+	for _, a := range cfg.Aggregates {
+		title := fmt.Sprintf(aggregateRowName, a.Type)
+		layout.addRow(title, a.Type, aggregateQueries(cfg.Counters))
+	}
+
+	return &Dashboard{
+		Title:         dashboardTitle,
+		UID:           dashboardUID,
+		SchemaVersion: dashboardSchema,
+		Time:          timeRange{From: "now-6h", To: "now"},
+		Templating: templating{
+			List: []variable{{
+				Name:  "datasource",
+				Label: "Data source",
+				Type:  "datasource",
+				Query: datasourceType,
+			}},
+		},
+		Panels: layout.panels,
+	}
+}
+
+func perfQueries(counters []config.Counter, name func(c *vmomi.CounterInfo) string) []panelQuery {
+	queries := []panelQuery{}
+	for _, c := range counters {
+		info := vmomi.CounterInfo{Group: c.Group, Name: c.Name, Rollup: c.Rollup}
+		queries = append(queries, panelQuery{
+			title:  fmt.Sprintf("%v.%v.%v", c.Group, c.Name, c.Rollup),
+			metric: name(&info),
+			legend: perfLegend,
+		})
+	}
+
+	return queries
+}
+
+func aggregateQueries(counters []config.Counter) []panelQuery {
+	queries := perfQueries(counters, ToAggregateGaugeID)
+	for idx := range queries {
+		queries[idx].legend = aggregateLegend
+	}
+
+	return queries
+}
+
+func (l *dashboardLayout) addRow(
+	title string,
+	entityType vmomi.ManagedEntityType,
+	queries []panelQuery,
+) {
+	l.panels = append(l.panels, Panel{
+		ID:      len(l.panels) + firstPanelID,
+		Type:    "row",
+		Title:   title,
+		GridPos: gridPos{H: rowHeight, W: panelWidth * panelColumns, X: empty, Y: l.y},
+	})
+	l.y += rowHeight
+
+	for idx, q := range queries {
+		l.panels = append(l.panels, Panel{
+			ID:      len(l.panels) + firstPanelID,
+			Type:    "timeseries",
+			Title:   q.title,
+			GridPos: panelPos(idx, l.y),
+			Datasource: &datasource{
+				Type: datasourceType,
+				UID:  datasourceVar,
+			},
+			Targets: []target{{
+				Expr:         fmt.Sprintf("%v{%v=%q}", q.metric, LabelEntityType, entityType),
+				LegendFormat: q.legend,
+				RefID:        "A",
+			}},
+		})
+	}
+
+	rows := (len(queries) + roundUp) / panelColumns
+	l.y += rows * panelHeight
+}
+
+func panelPos(idx int, y int) gridPos {
+	return gridPos{
+		H: panelHeight,
+		W: panelWidth,
+		X: (idx % panelColumns) * panelWidth,
+		Y: y + (idx/panelColumns)*panelHeight,
+	}
+}
@@ -0,0 +1,23 @@
+package exporter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/config"
+)
+
+func TestNewDashboard(t *testing.T) {
+	cfg, err := config.LoadFileConfig("testdata/config.yaml")
+	if err != nil {
+		t.Fatalf("LoadFileConfig: %v", err)
+	}
+
+	dashboard, err := json.MarshalIndent(NewDashboard(cfg), "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent: %v", err)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/dashboard.golden", dashboard)
+}
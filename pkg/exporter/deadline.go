@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+)
+
+const scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+const (
+	defaultScrapeTimeoutOffset = 0.5
+	float64Size                = 64
+	noTimeout                  = 0
+)
+
+type scrapeCollector struct {
+	*vmomiCollector
+	scrapeContext context.Context
+}
+
+func (c *scrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	// Error is exported as vmomi_exporter_scrape_success.
+	//revive:disable-next-line:unhandled-error
+	c.collectContext(c.scrapeContext, ch)
+}
+
+func metricsHandler(reg *prometheus.Registry, c *vmomiCollector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := scrapeContext(c.Context, r)
+		defer cancel()
+
+		// Register per request to collect with the scrape deadline.
+		scrapeReg := prometheus.NewRegistry()
+		scrapeReg.MustRegister(&scrapeCollector{
+			vmomiCollector: c,
+			scrapeContext:  ctx,
+		})
+
+		gatherers := prometheus.Gatherers{reg, scrapeReg}
+		handler := promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{Registry: reg})
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func scrapeContext(ctx context.Context, r *http.Request) (context.Context, context.CancelFunc) {
+	timeout := getScrapeTimeout(ctx, r)
+
+	var cctx context.Context
+	var cancel context.CancelFunc
+	if timeout == noTimeout {
+		cctx, cancel = context.WithCancel(ctx)
+	} else {
+		cctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	// Stop collection when Prometheus closes the connection.
+	stop := context.AfterFunc(r.Context(), cancel)
+
+	return cctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+func getScrapeTimeout(ctx context.Context, r *http.Request) time.Duration {
+	header := r.Header.Get(scrapeTimeoutHeader)
+	if header == "" {
+		return noTimeout
+	}
+
+	seconds, err := strconv.ParseFloat(header, float64Size)
+	if err != nil || seconds <= noTimeout {
+		slog.WarnContext(ctx, "Invalid scrape timeout", "header", header)
+		return noTimeout
+	}
+
+	offset, ok := ctx.Value(flag.ScrapeTimeoutOffsetKey{}).(float64)
+	if !ok || offset < noTimeout {
+		offset = defaultScrapeTimeoutOffset
+	}
+
+	if seconds > offset {
+		// Leave the safety margin to write response.
+		seconds -= offset
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+)
+
+const (
+	metricsPath = "/metrics"
+	noHeader    = ""
+	offset      = 0.5
+	// 10 seconds minus offset.
+	offsetTimeout = 9500 * time.Millisecond
+	// Offset is not subtracted from the timeout below it.
+	belowOffset = 300 * time.Millisecond
+)
+
+func newScrapeRequest(timeout string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, metricsPath, nil)
+	if timeout != noHeader {
+		r.Header.Set(scrapeTimeoutHeader, timeout)
+	}
+
+	return r
+}
+
+func TestGetScrapeTimeout(t *testing.T) {
+	ctx := context.WithValue(t.Context(), flag.ScrapeTimeoutOffsetKey{}, offset)
+
+	tests := []struct {
+		name     string
+		header   string
+		expected time.Duration
+	}{
+		{"missing", noHeader, noTimeout},
+		{"invalid", "ten", noTimeout},
+		{"negative", "-1", noTimeout},
+		{"offset", "10", offsetTimeout},
+		{"below offset", "0.3", belowOffset},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			timeout := getScrapeTimeout(ctx, newScrapeRequest(test.header))
+			if timeout != test.expected {
+				t.Errorf("getScrapeTimeout(%q) = %v", test.header, timeout)
+			}
+		})
+	}
+}
+
+func TestScrapeContext(t *testing.T) {
+	ctx, cancel := scrapeContext(t.Context(), newScrapeRequest(noHeader))
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Set deadline without header")
+	}
+
+	started := time.Now()
+	ctx, cancel = scrapeContext(t.Context(), newScrapeRequest("10"))
+	defer cancel()
+
+	// Default offset is subtracted.
+	deadline, ok := ctx.Deadline()
+	if expected := started.Add(offsetTimeout); !ok || deadline.Before(expected) {
+		t.Errorf("Deadline %v, expected %v", deadline, expected)
+	}
+}
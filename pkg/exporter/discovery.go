@@ -0,0 +1,146 @@
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+)
+
+const (
+	discoveryInitialBackoff = 1 * time.Second
+	discoveryMaxBackoff     = 5 * time.Minute
+	discoveryBackoffFactor  = 2
+)
+
+const disabledRefresh = 0
+
+// GaugeVec has a descriptor.
+const singleDesc = 1
+
+func (c *vmomiCollector) discover() {
+	backoff := discoveryInitialBackoff
+	for {
+		metrics, err := GetPerfGauge(c.Context)
+		c.health.SetLogin(toLoginResult(err))
+		c.health.SetDiscovery(err)
+		if err == nil {
+			c.setMetrics(metrics)
+			slog.InfoContext(c.Context, "Discovered counters", "metric_count", len(metrics))
+			break
+		}
+
+		slog.WarnContext(c.Context, "Could not discover counters", "error", err, "retry", backoff)
+
+		if !sleepContext(c.Context, backoff) {
+			return
+		}
+
+		backoff = min(backoff*discoveryBackoffFactor, discoveryMaxBackoff)
+	}
+
+	c.refresh()
+}
+
+func (c *vmomiCollector) refresh() {
+	interval := getCounterRefreshInterval(c.Context)
+	if interval == disabledRefresh {
+		return
+	}
+
+	for sleepContext(c.Context, interval) {
+		metrics, err := GetPerfGauge(c.Context)
+		c.health.SetLogin(toLoginResult(err))
+		if err != nil {
+			// Keep current counters until next refresh.
+			slog.WarnContext(c.Context, "Could not refresh counters", "error", err)
+			continue
+		}
+
+		c.metricRock.RLock()
+		added, removed := diffPerfGauge(c.metrics, metrics)
+		c.metricRock.RUnlock()
+
+		if len(added) == empty && len(removed) == empty {
+			slog.DebugContext(c.Context, "Not changed counters")
+			continue
+		}
+
+		c.setMetrics(metrics)
+		slog.InfoContext(
+			c.Context,
+			"Refreshed counters",
+			"metric_count", len(metrics),
+			"added", added,
+			"removed", removed,
+		)
+	}
+}
+
+func (c *vmomiCollector) discovered() bool {
+	c.metricRock.RLock()
+	defer c.metricRock.RUnlock()
+
+	return c.metrics != nil
+}
+
+func (c *vmomiCollector) setMetrics(metrics []PerfGauge) {
+	c.metricRock.Lock()
+	defer c.metricRock.Unlock()
+
+	c.metrics = metrics
+}
+
+func diffPerfGauge(current, next []PerfGauge) (added, removed []int32) {
+	currentDescs := toPerfGaugeDescs(current)
+	nextDescs := toPerfGaugeDescs(next)
+
+	added = []int32{}
+	for id, desc := range nextDescs {
+		if currentDescs[id] != desc {
+			added = append(added, id)
+		}
+	}
+
+	removed = []int32{}
+	for id, desc := range currentDescs {
+		if nextDescs[id] != desc {
+			removed = append(removed, id)
+		}
+	}
+
+	return added, removed
+}
+
+// toPerfGaugeDescs returns descriptor including help and labels such as unit per counter ID.
+func toPerfGaugeDescs(gauges []PerfGauge) map[int32]string {
+	descs := map[int32]string{}
+	for _, g := range gauges {
+		ch := make(chan *prometheus.Desc, singleDesc)
+		g.Gauge.Describe(ch)
+		descs[g.ID] = (<-ch).String()
+	}
+
+	return descs
+}
+
+func getCounterRefreshInterval(ctx context.Context) time.Duration {
+	interval, ok := ctx.Value(flag.CounterRefreshIntervalKey{}).(int)
+	if !ok || interval < disabledRefresh {
+		interval = disabledRefresh
+	}
+
+	return time.Duration(interval) * time.Second
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	cpuUsageID  = 2
+	memUsageID  = 24
+	diskUsageID = 125
+)
+
+func newCounterInfo(id int32, group string, unit string) vmomi.CounterInfo {
+	return vmomi.CounterInfo{
+		ID:          id,
+		Group:       group,
+		Name:        "usage",
+		Rollup:      "average",
+		Stats:       "rate",
+		Unit:        unit,
+		NameSummary: "Usage",
+	}
+}
+
+func newPerfGauges(infos ...vmomi.CounterInfo) []PerfGauge {
+	gauges := []PerfGauge{}
+	for _, i := range infos {
+		gauges = append(gauges, newPerfGauge(&i))
+	}
+
+	return gauges
+}
+
+func TestDiffPerfGauge(t *testing.T) {
+	cpu := newCounterInfo(cpuUsageID, "cpu", "percent")
+	mem := newCounterInfo(memUsageID, "mem", "percent")
+	current := newPerfGauges(cpu, mem)
+
+	added, removed := diffPerfGauge(current, newPerfGauges(cpu, mem))
+	if len(added)+len(removed) != empty {
+		t.Errorf("Not changed: added %v, removed %v", added, removed)
+	}
+
+	// Unit of mem is changed, and disk is added instead of cpu.
+	changed := mem
+	changed.Unit = "kiloBytes"
+	disk := newCounterInfo(diskUsageID, "disk", "kiloBytesPerSecond")
+
+	added, removed = diffPerfGauge(current, newPerfGauges(changed, disk))
+	slices.Sort(added)
+	slices.Sort(removed)
+	if !slices.Equal(added, []int32{memUsageID, diskUsageID}) ||
+		!slices.Equal(removed, []int32{cpuUsageID, memUsageID}) {
+		t.Errorf("Changed: added %v, removed %v", added, removed)
+	}
+}
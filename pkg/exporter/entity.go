@@ -3,56 +3,67 @@ package exporter
 import (
 	"context"
 	"log/slog"
+	"slices"
 
 	"github.com/9506hqwy/vmomi-exporter/pkg/config"
 	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
 )
 
-const empty = 0
+const (
+	empty       = 0
+	singleMatch = 1
+)
 
 func ToEntityFromRoot(ctx context.Context, roots []config.Root) (*[]vmomi.Entity, error) {
-	moTypes := filterEntityType(roots)
-	if moTypes == nil {
-		return nil, nil
+	specs := []vmomi.RootSpec{}
+	for _, r := range roots {
+		if r.IsRootFolder() {
+			return nil, nil
+		}
+
+		specs = append(specs, r.ToRootSpec())
 	}
 
-	entities, err := vmomi.GetEntityFromRoot(ctx, *moTypes)
+	matches, err := vmomi.FindRoots(ctx, specs)
 	if err != nil {
 		return nil, err
 	}
 
-	selected := filterEntity(entities, roots)
-
-	if len(selected) == empty {
-		slog.WarnContext(ctx, "Not found root", "roots", roots)
+	selected := []vmomi.Entity{}
+	for _, m := range matches {
+		warnRootMatch(ctx, m)
+		selected = appendUniqueEntity(selected, m.Entities)
 	}
 
 	return &selected, nil
 }
 
-func filterEntity(entities *[]vmomi.Entity, roots []config.Root) []vmomi.Entity {
-	selected := []vmomi.Entity{}
-	for _, e := range *entities {
-		for _, r := range roots {
-			if e.Type == r.Type && e.Name == r.Name {
-				selected = append(selected, e)
-				break
-			}
-		}
+func toRootSpecs(roots []config.Root) []vmomi.RootSpec {
+	specs := []vmomi.RootSpec{}
+	for _, r := range roots {
+		specs = append(specs, r.ToRootSpec())
 	}
 
-	return selected
+	return specs
 }
 
-func filterEntityType(roots []config.Root) *[]vmomi.ManagedEntityType {
-	moTypes := []vmomi.ManagedEntityType{}
-	for _, r := range roots {
-		if r.Type == vmomi.ManagedEntityTypeFolder && r.Name == "" {
-			return nil
+func appendUniqueEntity(entities []vmomi.Entity, added []vmomi.Entity) []vmomi.Entity {
+	for _, e := range added {
+		if !slices.Contains(entities, e) {
+			entities = append(entities, e)
 		}
+	}
 
-		moTypes = append(moTypes, r.Type)
+	return entities
+}
+
+func warnRootMatch(ctx context.Context, m vmomi.RootMatch) {
+	if len(m.Entities) == empty {
+		slog.WarnContext(ctx, "Not found root", "root", m.Spec)
 	}
 
-	return &moTypes
+	// Name is not unique across datacenters.
+	if len(m.Entities) > singleMatch && !m.Spec.IsGlob() {
+		slog.WarnContext(ctx, "Ambiguous root", "root", m.Spec, "entities", m.Entities)
+	}
 }
@@ -0,0 +1,276 @@
+package exporter
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/config"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	PresetMinimal  = "minimal"
+	PresetStandard = "standard"
+	PresetFull     = "full"
+)
+
+const defaultLevel = 0
+
+type GenerateOptions struct {
+	Preset string
+	Level  int32
+	Types  []vmomi.ManagedEntityType
+	Roots  []config.Root
+}
+
+type preset struct {
+	level    int32
+	groups   []string
+	types    []vmomi.ManagedEntityType
+	retrieve config.RetrieveConfig
+}
+
+var presets = map[string]preset{
+	PresetMinimal: {
+		level:  1,
+		groups: []string{"cpu", "mem"},
+		types: []vmomi.ManagedEntityType{
+			vmomi.ManagedEntityTypeHostSystem,
+			vmomi.ManagedEntityTypeVirtualMachine,
+		},
+		retrieve: config.RetrieveConfig{
+			IgnorDatastoreVM: true,
+			IgnoreNetworkVM:  true,
+		},
+	},
+	PresetStandard: {
+		level:  2,
+		groups: []string{"cpu", "datastore", "disk", "mem", "net"},
+		types: []vmomi.ManagedEntityType{
+			vmomi.ManagedEntityTypeClusterComputeResource,
+			vmomi.ManagedEntityTypeDatastore,
+			vmomi.ManagedEntityTypeHostSystem,
+			vmomi.ManagedEntityTypeVirtualMachine,
+		},
+		retrieve: *config.DefaultRetrieveConfig(),
+	},
+	PresetFull: {
+		level:  4,
+		groups: nil,
+		types: []vmomi.ManagedEntityType{
+			vmomi.ManagedEntityTypeClusterComputeResource,
+			vmomi.ManagedEntityTypeDatacenter,
+			vmomi.ManagedEntityTypeDatastore,
+			vmomi.ManagedEntityTypeHostSystem,
+			vmomi.ManagedEntityTypeResourcePool,
+			vmomi.ManagedEntityTypeVirtualMachine,
+		},
+		retrieve: *config.DefaultRetrieveConfig(),
+	},
+}
+
+func defaultGenerateOptions() GenerateOptions {
+	return GenerateOptions{
+		Preset: PresetStandard,
+		Level:  defaultLevel,
+		Types:  nil,
+		Roots:  nil,
+	}
+}
+
+func WithGeneratePreset(name string) func(o *GenerateOptions) {
+	return func(o *GenerateOptions) {
+		o.Preset = name
+	}
+}
+
+// WithGenerateLevel overrides max counter level of preset.
+func WithGenerateLevel(level int32) func(o *GenerateOptions) {
+	return func(o *GenerateOptions) {
+		o.Level = level
+	}
+}
+
+// WithGenerateTypes overrides entity types of preset.
+func WithGenerateTypes(types []vmomi.ManagedEntityType) func(o *GenerateOptions) {
+	return func(o *GenerateOptions) {
+		o.Types = types
+	}
+}
+
+func WithGenerateRoots(roots []config.Root) func(o *GenerateOptions) {
+	return func(o *GenerateOptions) {
+		o.Roots = roots
+	}
+}
+
+func GeneratePresets() []string {
+	return []string{
+		PresetMinimal,
+		PresetStandard,
+		PresetFull,
+	}
+}
+
+// GenerateConfig builds config from counters and inventory of vCenter,
+// and encodes it with summary and unit of each counter as comment.
+func GenerateConfig(ctx context.Context, opts ...func(o *GenerateOptions)) (string, error) {
+	o := defaultGenerateOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg, comments, err := buildConfig(ctx, o)
+	if err != nil {
+		return "", err
+	}
+
+	head := fmt.Sprintf("Generated with preset %v.", o.Preset)
+	return config.EncodeCommentedConfig(cfg, head, comments)
+}
+
+func buildConfig(
+	ctx context.Context,
+	o GenerateOptions,
+) (*config.Config, map[config.Counter]string, error) {
+	p, ok := presets[o.Preset]
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid preset %v", o.Preset)
+	}
+
+	rootEntities, err := resolveRoots(ctx, o.Roots)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counters, err := vmomi.GetCounterInfo(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := newGeneratedConfig(p, o)
+	available, err := vmomi.GetAvailableCounterIDs(ctx, rootEntities, toEntityTypes(cfg.Objects))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comments := map[config.Counter]string{}
+	level := cmp.Or(o.Level, p.level)
+	for _, c := range selectCounters(*counters, available, level, p.groups) {
+		cnt := config.Counter{Group: c.Group, Name: c.Name, Rollup: c.Rollup}
+		cfg.Counters = append(cfg.Counters, cnt)
+		comments[cnt] = fmt.Sprintf("%v (%v)", c.NameSummary, c.Unit)
+	}
+
+	return cfg, comments, validateConfig(cfg)
+}
+
+func newGeneratedConfig(p preset, o GenerateOptions) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Counters = []config.Counter{}
+	cfg.RetrieveConfig = p.retrieve
+
+	types := p.types
+	if len(o.Types) != empty {
+		types = o.Types
+	}
+
+	cfg.Objects = []config.Object{}
+	for _, t := range types {
+		cfg.Objects = append(cfg.Objects, config.Object{Type: &t})
+	}
+
+	if len(o.Roots) != empty {
+		cfg.Roots = o.Roots
+	}
+
+	return cfg
+}
+
+func toEntityTypes(objects []config.Object) []vmomi.ManagedEntityType {
+	types := []vmomi.ManagedEntityType{}
+	for _, o := range objects {
+		types = append(types, *o.Type)
+	}
+
+	return types
+}
+
+// selectCounters selects counters available on any entity type
+// because counter info does not have entity type.
+func selectCounters(
+	counters []vmomi.CounterInfo,
+	available []int32,
+	level int32,
+	groups []string,
+) []vmomi.CounterInfo {
+	selected := slices.DeleteFunc(slices.Clone(counters), func(c vmomi.CounterInfo) bool {
+		return c.Level > level ||
+			(groups != nil && !slices.Contains(groups, c.Group)) ||
+			!slices.Contains(available, c.ID)
+	})
+
+	slices.SortFunc(selected, func(a, b vmomi.CounterInfo) int {
+		return cmp.Or(
+			cmp.Compare(a.Group, b.Group),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Rollup, b.Rollup),
+		)
+	})
+
+	return slices.CompactFunc(selected, func(a, b vmomi.CounterInfo) bool {
+		return a.Group == b.Group && a.Name == b.Name && a.Rollup == b.Rollup
+	})
+}
+
+// resolveRoots returns nil if roots select all entities from root folder,
+// and fails if any root matches no entities.
+func resolveRoots(ctx context.Context, roots []config.Root) (*[]vmomi.Entity, error) {
+	isRootFolder := func(r config.Root) bool { return r.IsRootFolder() }
+	if len(roots) == empty || slices.ContainsFunc(roots, isRootFolder) {
+		return nil, nil
+	}
+
+	matches, err := vmomi.FindRoots(ctx, toRootSpecs(roots))
+	if err != nil {
+		return nil, err
+	}
+
+	entities := []vmomi.Entity{}
+	for _, m := range matches {
+		if len(m.Entities) == empty {
+			return nil, fmt.Errorf("not found root %v(%v)", m.Spec.Type, m.Spec.Name)
+		}
+
+		entities = appendUniqueEntity(entities, m.Entities)
+	}
+
+	return &entities, nil
+}
+
+// validateConfig checks generated config is accepted by exporter after decoding.
+func validateConfig(cfg *config.Config) error {
+	encoded, err := config.EncodeConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := config.DecodeConfig([]byte(encoded))
+	if err != nil {
+		return err
+	}
+
+	if len(decoded.Counters) == empty {
+		return errors.New("not found counters")
+	}
+
+	if _, err := toQueryObjects(decoded.Objects); err != nil {
+		return err
+	}
+
+	_, err = toAggregateSpecs(decoded.Aggregates)
+	return err
+}
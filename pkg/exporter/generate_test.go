@@ -0,0 +1,49 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/config"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+func TestGenerateConfig(t *testing.T) {
+	sim := vcsimtest.Start(t)
+
+	roots := []config.Root{{Type: vmomi.ManagedEntityTypeClusterComputeResource, Name: "DC0_C0"}}
+	conf, err := GenerateConfig(
+		sim.Context,
+		WithGeneratePreset(PresetMinimal),
+		WithGenerateRoots(roots),
+	)
+	if err != nil {
+		t.Fatalf("GenerateConfig: %v", err)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/generate_minimal.golden", []byte(conf))
+
+	cfg, err := config.DecodeConfig([]byte(conf))
+	if err != nil {
+		t.Fatalf("DecodeConfig: %v", err)
+	}
+
+	if len(cfg.Counters) == empty || cfg.Roots[empty] != roots[empty] {
+		t.Errorf("Unexpected config %v", cfg)
+	}
+}
+
+func TestGenerateConfigInvalid(t *testing.T) {
+	sim := vcsimtest.Start(t)
+
+	tests := []func(o *GenerateOptions){
+		WithGeneratePreset("none"),
+		WithGenerateRoots([]config.Root{{Type: vmomi.ManagedEntityTypeDatacenter, Name: "none"}}),
+	}
+
+	for _, opt := range tests {
+		if _, err := GenerateConfig(sim.Context, opt); err == nil {
+			t.Error("Accepted invalid option")
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	statusHealthy  = "healthy"
+	statusReady    = "ready"
+	statusNotReady = "not ready"
+)
+
+const (
+	reasonCredentialsRejected = "vCenter rejected credentials"
+	reasonDiscoveryFailed     = "counter discovery failed"
+	reasonDiscoveryPending    = "counter discovery not completed"
+	reasonUnreachable         = "vCenter unreachable"
+)
+
+var errDiscoveryPending = errors.New(reasonDiscoveryPending)
+
+type Health struct {
+	login     error
+	discovery error
+	rock      sync.RWMutex
+}
+
+type healthStatus struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func NewHealth() *Health {
+	return &Health{
+		login:     nil,
+		discovery: errDiscoveryPending,
+	}
+}
+
+func (h *Health) SetLogin(err error) {
+	h.rock.Lock()
+	defer h.rock.Unlock()
+
+	h.login = err
+}
+
+func (h *Health) SetDiscovery(err error) {
+	h.rock.Lock()
+	defer h.rock.Unlock()
+
+	h.discovery = err
+}
+
+func (h *Health) status() healthStatus {
+	h.rock.RLock()
+	defer h.rock.RUnlock()
+
+	if h.login != nil {
+		return toNotReadyStatus(loginReason(h.login), h.login)
+	}
+
+	if h.discovery != nil {
+		reason := reasonDiscoveryFailed
+		if errors.Is(h.discovery, errDiscoveryPending) {
+			reason = reasonDiscoveryPending
+		}
+
+		return toNotReadyStatus(reason, h.discovery)
+	}
+
+	return healthStatus{
+		Status: statusReady,
+	}
+}
+
+func HealthyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealthStatus(w, r, http.StatusOK, healthStatus{Status: statusHealthy})
+	})
+}
+
+func ReadyHandler(h *Health) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := h.status()
+
+		code := http.StatusOK
+		if status.Status != statusReady {
+			code = http.StatusServiceUnavailable
+		}
+
+		writeHealthStatus(w, r, code, status)
+	})
+}
+
+func writeHealthStatus(w http.ResponseWriter, r *http.Request, code int, status healthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		slog.WarnContext(r.Context(), "Could not write status", "error", err)
+	}
+}
+
+func toNotReadyStatus(reason string, err error) healthStatus {
+	return healthStatus{
+		Status: statusNotReady,
+		Reason: reason,
+		Error:  err.Error(),
+	}
+}
+
+func loginReason(err error) string {
+	if fault.Is(err, &types.InvalidLogin{}) {
+		return reasonCredentialsRejected
+	}
+
+	return reasonUnreachable
+}
+
+func toLoginResult(err error) error {
+	var loginErr *vmomi.LoginError
+	if errors.As(err, &loginErr) {
+		return loginErr
+	}
+
+	return nil
+}
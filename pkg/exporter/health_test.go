@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const readyPath = "/-/ready"
+
+func serveReady(t *testing.T, h *Health) (int, healthStatus) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	ReadyHandler(h).ServeHTTP(w, httptest.NewRequest(http.MethodGet, readyPath, nil))
+
+	var status healthStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	return w.Code, status
+}
+
+func TestReadyHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		login     error
+		discovery error
+		code      int
+		reason    string
+	}{
+		{
+			"credentials rejected",
+			&vmomi.LoginError{Err: soap.WrapVimFault(&types.InvalidLogin{})},
+			nil,
+			http.StatusServiceUnavailable,
+			reasonCredentialsRejected,
+		},
+		{
+			"unreachable",
+			&vmomi.LoginError{Err: errors.New("connection refused")},
+			nil,
+			http.StatusServiceUnavailable,
+			reasonUnreachable,
+		},
+		{
+			"discovery pending",
+			nil,
+			errDiscoveryPending,
+			http.StatusServiceUnavailable,
+			reasonDiscoveryPending,
+		},
+		{"ready", nil, nil, http.StatusOK, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := NewHealth()
+			h.SetLogin(test.login)
+			h.SetDiscovery(test.discovery)
+
+			code, status := serveReady(t, h)
+			if code != test.code || status.Reason != test.reason {
+				t.Errorf("ReadyHandler = %v, %v", code, status)
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ const (
 
 type PerfGauge struct {
 	ID    int32
+	Name  string
 	Gauge prometheus.GaugeVec
 }
 
@@ -32,34 +33,38 @@ func GetPerfGauge(ctx context.Context) ([]PerfGauge, error) {
 	}
 
 	metrics := []PerfGauge{}
-
 	for _, i := range *info {
-		metric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: ToPerfGaugeID(&i),
-			Help: i.NameSummary,
-			ConstLabels: prometheus.Labels{
-				LabelCounterID:   fmt.Sprintf("%v", i.ID),
-				LabelCounterStat: i.Stats,
-				LabelCounterUnit: i.Unit,
-			},
-		}, []string{
-			LabelCounterInterval,
-			LabelEntityID,
-			LabelEntityName,
-			LabelEntityType,
-			LabelEntityInstance,
-		})
-		gauge := PerfGauge{
-			ID:    i.ID,
-			Gauge: *metric,
-		}
-
-		metrics = append(metrics, gauge)
+		metrics = append(metrics, newPerfGauge(&i))
 	}
 
 	return metrics, nil
 }
 
+func newPerfGauge(i *vmomi.CounterInfo) PerfGauge {
+	name := ToPerfGaugeID(i)
+	metric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: name,
+		Help: i.NameSummary,
+		ConstLabels: prometheus.Labels{
+			LabelCounterID:   fmt.Sprintf("%v", i.ID),
+			LabelCounterStat: i.Stats,
+			LabelCounterUnit: i.Unit,
+		},
+	}, []string{
+		LabelCounterInterval,
+		LabelEntityID,
+		LabelEntityName,
+		LabelEntityType,
+		LabelEntityInstance,
+	})
+
+	return PerfGauge{
+		ID:    i.ID,
+		Name:  name,
+		Gauge: *metric,
+	}
+}
+
 func ToPerfGaugeID(c *vmomi.CounterInfo) string {
 	name := fmt.Sprintf("%v_%v_%v", c.Group, c.Name, c.Rollup)
 	return strings.ReplaceAll(name, ".", "_")
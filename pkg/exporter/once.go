@@ -0,0 +1,149 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/config"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	FormatJSON        = "json"
+	FormatOpenMetrics = "openmetrics"
+	FormatText        = "text"
+)
+
+type Sample struct {
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels"`
+	Value       float64           `json:"value"`
+	TimestampMs *int64            `json:"timestamp_ms,omitempty"`
+}
+
+type onceCollector struct {
+	*vmomiCollector
+	err error
+}
+
+func (c *onceCollector) Collect(ch chan<- prometheus.Metric) {
+	c.err = c.collectContext(c.Context, ch)
+}
+
+func ScrapeFormats() []string {
+	return []string{
+		FormatJSON,
+		FormatOpenMetrics,
+		FormatText,
+	}
+}
+
+// ScrapeOnce collects metrics once without background discovery and push, and writes them.
+func ScrapeOnce(ctx context.Context, w io.Writer, format string) (*vmomi.PhaseTimings, error) {
+	timings := vmomi.NewPhaseTimings()
+	ctx = context.WithValue(ctx, vmomi.PhaseTimingsKey{}, timings)
+
+	if !slices.Contains(ScrapeFormats(), format) {
+		return timings, fmt.Errorf("invalid format %v", format)
+	}
+
+	// Report invalid configuration instead of falling back to default.
+	if _, err := config.GetConfig(ctx); err != nil {
+		return timings, err
+	}
+
+	families, err := gatherOnce(ctx)
+	if err != nil {
+		return timings, err
+	}
+
+	return timings, writeFamilies(w, format, families)
+}
+
+func gatherOnce(ctx context.Context) ([]*dto.MetricFamily, error) {
+	c := &onceCollector{
+		vmomiCollector: buildVmomiCollector(WithVmomiCollectorContext(ctx)),
+		err:            nil,
+	}
+
+	metrics, err := GetPerfGauge(c.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setMetrics(metrics)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	families, err := reg.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	return families, c.err
+}
+
+func writeFamilies(w io.Writer, format string, families []*dto.MetricFamily) error {
+	if format == FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(toSamples(families))
+	}
+
+	expFormat := expfmt.NewFormat(expfmt.TypeTextPlain)
+	if format == FormatOpenMetrics {
+		expFormat = expfmt.NewFormat(expfmt.TypeOpenMetrics)
+	}
+
+	enc := expfmt.NewEncoder(w, expFormat)
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+
+	if closer, ok := enc.(expfmt.Closer); ok {
+		// Write `# EOF` for OpenMetrics.
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func toSamples(families []*dto.MetricFamily) []Sample {
+	samples := []Sample{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			samples = append(samples, toSample(mf.GetName(), m))
+		}
+	}
+
+	return samples
+}
+
+func toSample(name string, m *dto.Metric) Sample {
+	labels := map[string]string{}
+	for _, l := range m.GetLabel() {
+		labels[l.GetName()] = l.GetValue()
+	}
+
+	value := m.GetGauge().GetValue()
+	if m.GetCounter() != nil {
+		value = m.GetCounter().GetValue()
+	}
+
+	return Sample{
+		Name:        name,
+		Labels:      labels,
+		Value:       value,
+		TimestampMs: m.TimestampMs,
+	}
+}
@@ -0,0 +1,56 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+func findSample(samples []Sample, name string) *Sample {
+	for _, s := range samples {
+		if s.Name == name {
+			return &s
+		}
+	}
+
+	return nil
+}
+
+func TestScrapeOnce(t *testing.T) {
+	sim := vcsimtest.Start(t)
+	ctx := context.WithValue(sim.Context, flag.ExporterConfigKey{}, "testdata/config.yaml")
+
+	var out bytes.Buffer
+	timings, err := ScrapeOnce(ctx, &out, FormatJSON)
+	if err != nil {
+		t.Fatalf("ScrapeOnce: %v", err)
+	}
+
+	samples := []Sample{}
+	if err := json.Unmarshal(out.Bytes(), &samples); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	success := findSample(samples, "vmomi_exporter_scrape_success")
+	if success == nil || success.Value != scrapeSucceeded {
+		t.Errorf("Scrape failed %v", success)
+	}
+
+	for _, phase := range vmomi.Phases() {
+		if timings.Get(phase) <= time.Duration(empty) {
+			t.Errorf("Not observed phase %v", phase)
+		}
+	}
+}
+
+func TestScrapeOnceInvalidFormat(t *testing.T) {
+	if _, err := ScrapeOnce(t.Context(), &bytes.Buffer{}, "xml"); err == nil {
+		t.Error("Accepted invalid format")
+	}
+}
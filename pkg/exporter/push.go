@@ -0,0 +1,160 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/config"
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+	"github.com/9506hqwy/vmomi-exporter/pkg/sink"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const defaultPushInterval = 1 * time.Minute
+
+type pushTarget struct {
+	name string
+	sink sink.Sink
+}
+
+func (c *vmomiCollector) push() {
+	targets, err := newPushTargets(c.Context, &c.Config.PushConfig)
+	if err != nil {
+		slog.ErrorContext(c.Context, "Could not start push", "error", err)
+		return
+	}
+
+	if len(targets) == empty {
+		return
+	}
+
+	interval := getPushInterval(&c.Config.PushConfig)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.pushOnce(targets, interval); err != nil {
+			slog.WarnContext(c.Context, "Could not push", "error", err)
+		}
+
+		select {
+		case <-c.Context.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *vmomiCollector) pushOnce(targets []pushTarget, interval time.Duration) error {
+	ctx, cancel := context.WithTimeout(c.Context, interval)
+	defer cancel()
+
+	result, err := c.queryPush(ctx)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, t := range targets {
+		if err := t.sink.Export(ctx, result.Metrics); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", t.name, err))
+			continue
+		}
+
+		slog.DebugContext(ctx, "Pushed", "target", t.name, "metric_count", len(result.Metrics))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *vmomiCollector) queryPush(ctx context.Context) (*vmomi.QueryResult, error) {
+	roots, err := ToEntityFromRoot(ctx, c.Config.Roots)
+	c.health.SetLogin(toLoginResult(err))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := queryVmomi(ctx, &c.Config, roots)
+	c.health.SetLogin(toLoginResult(err))
+	return result, err
+}
+
+func newPushTargets(ctx context.Context, cfg *config.PushConfig) ([]pushTarget, error) {
+	targets, err := newExporterTargets(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sinks, err := newSinkTargets(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(targets, sinks...), nil
+}
+
+func newExporterTargets(ctx context.Context, cfg *config.PushConfig) ([]pushTarget, error) {
+	targets := []pushTarget{}
+
+	if cfg.OTLP != nil {
+		exporter, err := cfg.OTLP.ToExporter(getServerAddress(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, pushTarget{name: "otlp", sink: exporter})
+	}
+
+	if cfg.RemoteWrite != nil {
+		exporter := &remoteWriteExporter{client: cfg.RemoteWrite.ToClient()}
+		targets = append(targets, pushTarget{name: "remote_write", sink: exporter})
+	}
+
+	return targets, nil
+}
+
+func newSinkTargets(cfg *config.PushConfig) ([]pushTarget, error) {
+	targets := []pushTarget{}
+
+	if cfg.InfluxDB != nil {
+		influxdb, err := cfg.InfluxDB.ToSink()
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, pushTarget{name: "influxdb", sink: influxdb})
+	}
+
+	if cfg.Graphite != nil {
+		targets = append(targets, pushTarget{name: "graphite", sink: cfg.Graphite.ToSink()})
+	}
+
+	return targets, nil
+}
+
+func getPushInterval(cfg *config.PushConfig) time.Duration {
+	if cfg.Interval <= time.Duration(empty) {
+		return defaultPushInterval
+	}
+
+	return cfg.Interval
+}
+
+func getServerAddress(ctx context.Context) string {
+	targetURL, ok := ctx.Value(flag.TargetURLKey{}).(string)
+	if !ok {
+		return ""
+	}
+
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return ""
+	}
+
+	return u.Host
+}
@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/remotewrite"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const labelMetricName = "__name__"
+
+type remoteWriteExporter struct {
+	client *remotewrite.Client
+}
+
+func (e *remoteWriteExporter) Export(ctx context.Context, metrics []vmomi.Metric) error {
+	return e.client.Write(ctx, toSeries(metrics))
+}
+
+func toSeries(metrics []vmomi.Metric) []remotewrite.Series {
+	series := []remotewrite.Series{}
+	seriesIndex := map[string]int{}
+	for _, m := range metrics {
+		labels := toRemoteWriteLabels(m)
+		sample := remotewrite.Sample{
+			Value:     float64(m.Value),
+			Timestamp: m.Timestamp,
+		}
+
+		key := fmt.Sprint(labels)
+		if i, ok := seriesIndex[key]; ok {
+			series[i].Samples = append(series[i].Samples, sample)
+			continue
+		}
+
+		seriesIndex[key] = len(series)
+		series = append(series, remotewrite.Series{
+			Labels:  labels,
+			Samples: []remotewrite.Sample{sample},
+		})
+	}
+
+	return series
+}
+
+func toRemoteWriteLabels(m vmomi.Metric) []remotewrite.Label {
+	inst := m.Instance
+	if inst == "" {
+		inst = m.Entity.Name
+	}
+
+	labels := []remotewrite.Label{
+		{Name: labelMetricName, Value: ToPerfGaugeID(&m.Counter)},
+		{Name: LabelCounterID, Value: fmt.Sprintf("%v", m.Counter.ID)},
+		{Name: LabelCounterStat, Value: m.Counter.Stats},
+		{Name: LabelCounterUnit, Value: m.Counter.Unit},
+		{Name: LabelCounterInterval, Value: fmt.Sprintf("%v", m.Interval)},
+		{Name: LabelEntityID, Value: m.Entity.ID},
+		{Name: LabelEntityName, Value: m.Entity.Name},
+		{Name: LabelEntityType, Value: string(m.Entity.Type)},
+		{Name: LabelEntityInstance, Value: inst},
+	}
+
+	// Remote write requires labels sorted by name.
+	slices.SortFunc(labels, func(a, b remotewrite.Label) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return labels
+}
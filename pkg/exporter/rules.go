@@ -0,0 +1,212 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/config"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const ruleGroupName = "vmomi-exporter"
+
+const (
+	// vSphere default sampling period seconds of realtime and the smallest historical interval.
+	defaultRealtimeSeconds   = 20
+	defaultHistoricalSeconds = 300
+	millisPerSecond          = 1000
+)
+
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+type Rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type starterRule struct {
+	counters   []config.Counter
+	entityType vmomi.ManagedEntityType
+	rule       func(metrics []string, interval int) Rule
+}
+
+var (
+	hostCPUReady = config.Counter{Group: "cpu", Name: "ready", Rollup: "summation"}
+
+	datastoreReadLatency = config.Counter{
+		Group:  "datastore",
+		Name:   "totalReadLatency",
+		Rollup: "average",
+	}
+
+	datastoreWriteLatency = config.Counter{
+		Group:  "datastore",
+		Name:   "totalWriteLatency",
+		Rollup: "average",
+	}
+)
+
+var starterRules = []starterRule{
+	{
+		counters:   []config.Counter{hostCPUReady},
+		entityType: vmomi.ManagedEntityTypeHostSystem,
+		rule: func(metrics []string, interval int) Rule {
+			// Ready time is milliseconds in the sampling period.
+			return Rule{
+				Alert: "VmomiHostCPUReadyHigh",
+				Expr: fmt.Sprintf(
+					`%v{%v="HostSystem",%v="",%v="%v"} / %v * 100 > 10`,
+					metrics[0],
+					LabelEntityType,
+					LabelEntityInstance,
+					LabelCounterInterval,
+					interval,
+					interval*millisPerSecond,
+				),
+				For:    "15m",
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary": fmt.Sprintf(
+						"CPU ready of host {{ $labels.%v }} is over 10%%.",
+						LabelEntityName,
+					),
+				},
+			}
+		},
+	},
+	{
+		counters:   []config.Counter{datastoreReadLatency, datastoreWriteLatency},
+		entityType: vmomi.ManagedEntityTypeHostSystem,
+		rule: func(metrics []string, _ int) Rule {
+			return Rule{
+				Alert: "VmomiDatastoreLatencyHigh",
+				Expr: fmt.Sprintf(
+					"%v > 20 or %v > 20",
+					maxLatency(metrics[0]),
+					maxLatency(metrics[1]),
+				),
+				For:    "10m",
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary": fmt.Sprintf(
+						"Datastore {{ $labels.%v }} latency "+
+							"from host {{ $labels.%v }} is over 20ms.",
+						LabelEntityInstance,
+						LabelEntityName,
+					),
+				},
+			}
+		},
+	},
+}
+
+// NewRuleFile creates Prometheus rule file which has starter alerts.
+// An alert is added only if the counters and the entity type are configured.
+func NewRuleFile(ctx context.Context, cfg *config.Config) *RuleFile {
+	rules := []Rule{scrapeFailureRule()}
+	for _, s := range starterRules {
+		object := findObject(cfg.Objects, s.entityType)
+		if object == nil || !hasCounters(cfg.Counters, s.counters) {
+			slog.WarnContext(ctx, "Skip rule", "counters", s.counters, "type", s.entityType)
+			continue
+		}
+
+		interval, err := intervalSeconds(*object)
+		if err != nil {
+			slog.WarnContext(ctx, "Skip rule", "error", err, "type", s.entityType)
+			continue
+		}
+
+		rules = append(rules, s.rule(ruleMetrics(s.counters), interval))
+	}
+
+	return &RuleFile{
+		Groups: []RuleGroup{{Name: ruleGroupName, Rules: rules}},
+	}
+}
+
+func ruleMetrics(counters []config.Counter) []string {
+	metrics := []string{}
+	for _, c := range counters {
+		metrics = append(metrics, ToPerfGaugeID(&vmomi.CounterInfo{
+			Group:  c.Group,
+			Name:   c.Name,
+			Rollup: c.Rollup,
+		}))
+	}
+
+	return metrics
+}
+
+func scrapeFailureRule() Rule {
+	return Rule{
+		Alert:  "VmomiExporterScrapeFailed",
+		Expr:   "vmomi_exporter_scrape_success == 0",
+		For:    "5m",
+		Labels: map[string]string{"severity": "critical"},
+		Annotations: map[string]string{
+			"summary": "Exporter {{ $labels.instance }} failed to scrape vCenter.",
+		},
+	}
+}
+
+func maxLatency(metric string) string {
+	return fmt.Sprintf(
+		`max by (%v, %v) (%v{%v="HostSystem"})`,
+		LabelEntityName,
+		LabelEntityInstance,
+		metric,
+		LabelEntityType,
+	)
+}
+
+func findObject(objects []config.Object, entityType vmomi.ManagedEntityType) *config.Object {
+	for _, o := range objects {
+		if o.Type != nil && *o.Type == entityType {
+			return &o
+		}
+	}
+
+	return nil
+}
+
+// intervalSeconds returns sampling period of the configured interval
+// assuming vSphere default intervals because the rule is created without server.
+func intervalSeconds(object config.Object) (int, error) {
+	switch object.Interval {
+	case vmomi.IntervalAuto, vmomi.IntervalRealtime:
+		return defaultRealtimeSeconds, nil
+	case vmomi.IntervalHistorical:
+		return defaultHistoricalSeconds, nil
+	default:
+		seconds, err := strconv.Atoi(object.Interval)
+		if err != nil {
+			return empty, fmt.Errorf("invalid interval %v", object.Interval)
+		}
+
+		return seconds, nil
+	}
+}
+
+func hasCounters(counters []config.Counter, required []config.Counter) bool {
+	for _, r := range required {
+		if !slices.Contains(counters, r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/config"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+// Scrape failure rule is always added.
+const scrapeRules = 1
+
+func ruleNames(r *RuleFile) []string {
+	names := []string{}
+	for _, g := range r.Groups {
+		for _, rule := range g.Rules {
+			names = append(names, rule.Alert)
+		}
+	}
+
+	return names
+}
+
+func TestNewRuleFile(t *testing.T) {
+	host := vmomi.ManagedEntityTypeHostSystem
+	cfg := config.DefaultConfig()
+
+	if names := ruleNames(NewRuleFile(t.Context(), cfg)); len(names) != scrapeRules {
+		t.Errorf("Unexpected rules %v", names)
+	}
+
+	cfg.Counters = []config.Counter{hostCPUReady, datastoreReadLatency, datastoreWriteLatency}
+	cfg.Objects = []config.Object{{Type: &host}}
+
+	names := ruleNames(NewRuleFile(t.Context(), cfg))
+	if len(names) != len(starterRules)+scrapeRules {
+		t.Errorf("Unexpected rules %v", names)
+	}
+}
+
+func TestNewRuleFileInterval(t *testing.T) {
+	host := vmomi.ManagedEntityTypeHostSystem
+
+	tests := []struct {
+		interval string
+		expected string
+	}{
+		{vmomi.IntervalAuto, `counter_interval="20"} / 20000 `},
+		{vmomi.IntervalRealtime, `counter_interval="20"} / 20000 `},
+		{vmomi.IntervalHistorical, `counter_interval="300"} / 300000 `},
+		{"1800", `counter_interval="1800"} / 1800000 `},
+	}
+
+	for _, test := range tests {
+		cfg := config.DefaultConfig()
+		cfg.Counters = []config.Counter{hostCPUReady}
+		cfg.Objects = []config.Object{{Type: &host, Interval: test.interval}}
+
+		// Starter rules follow scrape failure rule.
+		rules := NewRuleFile(t.Context(), cfg).Groups[empty].Rules
+		if expr := rules[scrapeRules].Expr; !strings.Contains(expr, test.expected) {
+			t.Errorf("Interval %v: %v", test.interval, expr)
+		}
+	}
+}
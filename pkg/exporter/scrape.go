@@ -0,0 +1,188 @@
+package exporter
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
+)
+
+const LabelRetryReason = "reason"
+
+const (
+	scrapeFailed    = 0
+	scrapeSucceeded = 1
+)
+
+const failedEntity = 1
+
+var (
+	scrapeSuccessDesc = prometheus.NewDesc(
+		"vmomi_exporter_scrape_success",
+		"Whether the last scrape of vSphere server succeeded.",
+		nil,
+		nil,
+	)
+
+	scrapeDurationDesc = prometheus.NewDesc(
+		"vmomi_exporter_scrape_duration_seconds",
+		"Duration of the last scrape of vSphere server.",
+		nil,
+		nil,
+	)
+
+	scrapePartialDesc = prometheus.NewDesc(
+		"vmomi_exporter_scrape_partial",
+		"Whether the last scrape of vSphere server exported partial results.",
+		nil,
+		nil,
+	)
+
+	scrapeFailedChunksDesc = prometheus.NewDesc(
+		"vmomi_exporter_scrape_failed_chunks",
+		"Number of failed query chunks in the last scrape of vSphere server.",
+		nil,
+		nil,
+	)
+
+	scrapeFailedEntityDesc = prometheus.NewDesc(
+		"vmomi_exporter_scrape_failed_entity",
+		"Entity of failed query chunks in the last scrape of vSphere server.",
+		[]string{LabelEntityID, LabelEntityName, LabelEntityType},
+		nil,
+	)
+
+	scrapeSkippedEntitiesDesc = prometheus.NewDesc(
+		"vmomi_exporter_scrape_skipped_entities",
+		"Number of entities skipped by excludes in the last scrape of vSphere server.",
+		nil,
+		nil,
+	)
+
+	apiRetriesDesc = prometheus.NewDesc(
+		"vmomi_exporter_api_retries_total",
+		"Number of retried vSphere API calls.",
+		[]string{LabelRetryReason},
+		nil,
+	)
+
+	queryConcurrencyDesc = prometheus.NewDesc(
+		"vmomi_exporter_query_concurrency",
+		"Concurrency of QueryPerf tuned by observed latency.",
+		nil,
+		nil,
+	)
+
+	queryMetricChunkSizeDesc = prometheus.NewDesc(
+		"vmomi_exporter_query_metric_chunk_size",
+		"Metric ID chunk size learned from maxQueryMetrics fault. (0 is not learned)",
+		nil,
+		nil,
+	)
+)
+
+func sendScrapeMetrics(
+	ch chan<- prometheus.Metric,
+	duration time.Duration,
+	result *vmomi.QueryResult,
+	err error,
+) {
+	success := scrapeSucceeded
+	if err != nil {
+		success = scrapeFailed
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		scrapeSuccessDesc,
+		prometheus.GaugeValue,
+		float64(success),
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		scrapeDurationDesc,
+		prometheus.GaugeValue,
+		duration.Seconds(),
+	)
+
+	sendPartialMetrics(ch, result)
+	sendSkippedMetrics(ch, result)
+
+	for reason, count := range sx.Retries() {
+		ch <- prometheus.MustNewConstMetric(
+			apiRetriesDesc,
+			prometheus.CounterValue,
+			float64(count),
+			reason,
+		)
+	}
+
+	sendSchedulerMetrics(ch)
+}
+
+func sendPartialMetrics(ch chan<- prometheus.Metric, result *vmomi.QueryResult) {
+	failedChunks := empty
+	failedEntities := []vmomi.Entity{}
+	if result != nil {
+		failedChunks = result.FailedChunks
+		failedEntities = result.FailedEntities
+	}
+
+	partial := scrapeFailed
+	if failedChunks != empty {
+		partial = scrapeSucceeded
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		scrapePartialDesc,
+		prometheus.GaugeValue,
+		float64(partial),
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		scrapeFailedChunksDesc,
+		prometheus.GaugeValue,
+		float64(failedChunks),
+	)
+
+	for _, e := range failedEntities {
+		ch <- prometheus.MustNewConstMetric(
+			scrapeFailedEntityDesc,
+			prometheus.GaugeValue,
+			failedEntity,
+			e.ID,
+			e.Name,
+			string(e.Type),
+		)
+	}
+}
+
+func sendSkippedMetrics(ch chan<- prometheus.Metric, result *vmomi.QueryResult) {
+	skipped := empty
+	if result != nil {
+		skipped = result.SkippedEntities
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		scrapeSkippedEntitiesDesc,
+		prometheus.GaugeValue,
+		float64(skipped),
+	)
+}
+
+func sendSchedulerMetrics(ch chan<- prometheus.Metric) {
+	state := vmomi.GetSchedulerState()
+
+	ch <- prometheus.MustNewConstMetric(
+		queryConcurrencyDesc,
+		prometheus.GaugeValue,
+		float64(state.Concurrency),
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		queryMetricChunkSizeDesc,
+		prometheus.GaugeValue,
+		float64(state.MetricChunkSize),
+	)
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
 	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
@@ -31,15 +30,23 @@ func Run(ctx context.Context) error {
 		return errors.New("exporter_url not found in context")
 	}
 
+	health := NewHealth()
+
 	reg := prometheus.NewRegistry()
 
 	reg.MustRegister(
 		collectors.NewGoCollector(),
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
-		NewVmomiCollector(WithVmomiCollectorContext(ctx)),
 	)
 
-	http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+	collector := createVmomiCollector(
+		WithVmomiCollectorContext(ctx),
+		WithVmomiCollectorHealth(health),
+	)
+
+	http.Handle("/metrics", metricsHandler(reg, collector))
+	http.Handle("/-/healthy", HealthyHandler())
+	http.Handle("/-/ready", ReadyHandler(health))
 
 	slog.Info("HTTP server started", "url", exporterURL)
 	return http.ListenAndServe(exporterURL, nil)
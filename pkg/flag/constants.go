@@ -5,12 +5,26 @@ package flag
 type TargetURLKey struct{}
 type TargetUserKey struct{}
 type TargetPasswordKey struct{}
+type TargetPasswordFileKey struct{}
 type TargetNoVerifySSLKey struct{}
+type TargetCAFileKey struct{}
+type TargetThumbprintKey struct{}
+type TargetTLSServerNameKey struct{}
 type TargetTimeoutKey struct{}
+type TargetRetryMaxAttemptsKey struct{}
+type TargetRetryInitialBackoffKey struct{}
+type TargetRetryMaxBackoffKey struct{}
 type TargetMaxConcurrency struct{}
 type TargetEntityChunkSize struct{}
+type TargetMetricChunkSizeKey struct{}
+type TargetQueryLatencyKey struct{}
+type TargetPartialResultsKey struct{}
+type TargetRecordDirKey struct{}
+type TargetReplayDirKey struct{}
 type ExporterConfigKey struct{}
 type ExporterURLKey struct{}
 type LogLevelKey struct{}
+type CounterRefreshIntervalKey struct{}
+type ScrapeTimeoutOffsetKey struct{}
 
 //revive:enable:max-public-structs
@@ -0,0 +1,201 @@
+package otlp
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	pwx "github.com/9506hqwy/vmomi-exporter/pkg/protowireex"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+// Field numbers in opentelemetry/proto/collector/metrics/v1/metrics_service.proto
+// and its dependencies.
+const (
+	requestResourceMetrics   = protowire.Number(1)
+	resourceMetricsResource  = protowire.Number(1)
+	resourceMetricsScope     = protowire.Number(2)
+	resourceAttributes       = protowire.Number(1)
+	keyValueKey              = protowire.Number(1)
+	keyValueValue            = protowire.Number(2)
+	anyValueString           = protowire.Number(1)
+	scopeMetricsScope        = protowire.Number(1)
+	scopeMetricsMetrics      = protowire.Number(2)
+	scopeName                = protowire.Number(1)
+	metricName               = protowire.Number(1)
+	metricDescription        = protowire.Number(2)
+	metricUnit               = protowire.Number(3)
+	metricGauge              = protowire.Number(5)
+	gaugeDataPoints          = protowire.Number(1)
+	numberDataPointTime      = protowire.Number(3)
+	numberDataPointAsInt     = protowire.Number(6)
+	numberDataPointAttribute = protowire.Number(7)
+)
+
+const (
+	AttributeEntityID      = "vcenter.entity.id"
+	AttributeEntityName    = "vcenter.entity.name"
+	AttributeEntityType    = "vcenter.entity.type"
+	AttributeInstance      = "instance"
+	AttributeInterval      = "interval"
+	AttributeServerAddress = "server.address"
+	AttributeServiceName   = "service.name"
+	AttributeStat          = "stat"
+)
+
+const (
+	metricPrefix = "vmomi"
+	scope        = "github.com/9506hqwy/vmomi-exporter"
+	serviceName  = "vmomi-exporter"
+)
+
+// Convert to UCUM unit.
+var units = map[string]string{
+	"celsius":            "Cel",
+	"joule":              "J",
+	"kiloBytes":          "KiBy",
+	"kiloBytesPerSecond": "KiBy/s",
+	"megaBytes":          "MiBy",
+	"megaHertz":          "MHz",
+	"microsecond":        "us",
+	"millisecond":        "ms",
+	"number":             "1",
+	"second":             "s",
+	"watt":               "W",
+}
+
+type attribute struct {
+	key   string
+	value string
+}
+
+type entityMetrics struct {
+	entity   vmomi.Entity
+	counters []*counterMetrics
+}
+
+type counterMetrics struct {
+	counter vmomi.CounterInfo
+	points  []vmomi.Metric
+}
+
+func encodeRequest(metrics []vmomi.Metric, serverAddress string) []byte {
+	var b []byte
+	for _, e := range groupMetrics(metrics) {
+		b = pwx.AppendMessage(b, requestResourceMetrics, encodeResourceMetrics(e, serverAddress))
+	}
+
+	return b
+}
+
+func encodeResourceMetrics(e *entityMetrics, serverAddress string) []byte {
+	resource := []attribute{
+		{AttributeServiceName, serviceName},
+		{AttributeServerAddress, serverAddress},
+		{AttributeEntityID, e.entity.ID},
+		{AttributeEntityName, e.entity.Name},
+		{AttributeEntityType, string(e.entity.Type)},
+	}
+
+	var r []byte
+	for _, a := range resource {
+		r = pwx.AppendMessage(r, resourceAttributes, encodeAttribute(a))
+	}
+
+	var s []byte
+	s = pwx.AppendMessage(s, scopeMetricsScope, pwx.AppendString(nil, scopeName, scope))
+	for _, c := range e.counters {
+		s = pwx.AppendMessage(s, scopeMetricsMetrics, encodeMetric(c))
+	}
+
+	var b []byte
+	b = pwx.AppendMessage(b, resourceMetricsResource, r)
+	b = pwx.AppendMessage(b, resourceMetricsScope, s)
+	return b
+}
+
+func encodeMetric(c *counterMetrics) []byte {
+	var g []byte
+	for _, p := range c.points {
+		g = pwx.AppendMessage(g, gaugeDataPoints, encodeDataPoint(p))
+	}
+
+	var b []byte
+	b = pwx.AppendString(b, metricName, ToMetricName(&c.counter))
+	b = pwx.AppendString(b, metricDescription, c.counter.NameSummary)
+	b = pwx.AppendString(b, metricUnit, toUnit(c.counter.Unit))
+	b = pwx.AppendMessage(b, metricGauge, g)
+	return b
+}
+
+func encodeDataPoint(m vmomi.Metric) []byte {
+	attributes := []attribute{
+		{AttributeInstance, m.Instance},
+		{AttributeInterval, fmt.Sprintf("%v", m.Interval)},
+		{AttributeStat, m.Counter.Stats},
+	}
+
+	var b []byte
+	for _, a := range attributes {
+		b = pwx.AppendMessage(b, numberDataPointAttribute, encodeAttribute(a))
+	}
+
+	b = protowire.AppendTag(b, numberDataPointTime, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, uint64(m.Timestamp.UnixNano()))
+	b = protowire.AppendTag(b, numberDataPointAsInt, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, uint64(m.Value))
+	return b
+}
+
+func encodeAttribute(a attribute) []byte {
+	var b []byte
+	b = pwx.AppendString(b, keyValueKey, a.key)
+	b = pwx.AppendMessage(b, keyValueValue, pwx.AppendString(nil, anyValueString, a.value))
+	return b
+}
+
+func groupMetrics(metrics []vmomi.Metric) []*entityMetrics {
+	entities := []*entityMetrics{}
+	entityIndex := map[vmomi.Entity]*entityMetrics{}
+	for _, m := range metrics {
+		e, ok := entityIndex[m.Entity]
+		if !ok {
+			e = &entityMetrics{
+				entity:   m.Entity,
+				counters: []*counterMetrics{},
+			}
+			entityIndex[m.Entity] = e
+			entities = append(entities, e)
+		}
+
+		e.add(m)
+	}
+
+	return entities
+}
+
+func (e *entityMetrics) add(m vmomi.Metric) {
+	for _, c := range e.counters {
+		if c.counter.ID == m.Counter.ID {
+			c.points = append(c.points, m)
+			return
+		}
+	}
+
+	e.counters = append(e.counters, &counterMetrics{
+		counter: m.Counter,
+		points:  []vmomi.Metric{m},
+	})
+}
+
+func ToMetricName(c *vmomi.CounterInfo) string {
+	return fmt.Sprintf("%v.%v.%v.%v", metricPrefix, c.Group, c.Name, c.Rollup)
+}
+
+func toUnit(unit string) string {
+	if u, ok := units[unit]; ok {
+		return u
+	}
+
+	return unit
+}
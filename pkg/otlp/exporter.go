@@ -0,0 +1,223 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+const (
+	defaultTimeout  = 10 * time.Second
+	empty           = 0
+	grpcFlagIndex   = 0
+	grpcHeaderSize  = 5
+	grpcLengthIndex = 1
+	grpcOK          = "0"
+	grpcPath        = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+	httpPath        = "/v1/metrics"
+	uncompressed    = byte(0)
+	unset           = ""
+)
+
+type ExporterOptions struct {
+	Protocol      string
+	Headers       map[string]string
+	Timeout       time.Duration
+	ServerAddress string
+}
+
+type Exporter struct {
+	endpoint      string
+	protocol      string
+	headers       map[string]string
+	serverAddress string
+	client        *http.Client
+}
+
+func defaultExporterOptions() ExporterOptions {
+	return ExporterOptions{
+		Protocol:      ProtocolGRPC,
+		Headers:       map[string]string{},
+		Timeout:       defaultTimeout,
+		ServerAddress: unset,
+	}
+}
+
+func WithProtocol(protocol string) func(o *ExporterOptions) {
+	return func(o *ExporterOptions) {
+		o.Protocol = protocol
+	}
+}
+
+func WithHeaders(headers map[string]string) func(o *ExporterOptions) {
+	return func(o *ExporterOptions) {
+		o.Headers = headers
+	}
+}
+
+func WithTimeout(timeout time.Duration) func(o *ExporterOptions) {
+	return func(o *ExporterOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithServerAddress(address string) func(o *ExporterOptions) {
+	return func(o *ExporterOptions) {
+		o.ServerAddress = address
+	}
+}
+
+func NewExporter(endpoint string, opts ...func(o *ExporterOptions)) (*Exporter, error) {
+	opt := defaultExporterOptions()
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if err := configureProtocol(transport, u, opt.Protocol); err != nil {
+		return nil, err
+	}
+
+	return &Exporter{
+		endpoint:      u.String(),
+		protocol:      opt.Protocol,
+		headers:       opt.Headers,
+		serverAddress: opt.ServerAddress,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   opt.Timeout,
+		},
+	}, nil
+}
+
+func configureProtocol(transport *http.Transport, u *url.URL, protocol string) error {
+	switch protocol {
+	case ProtocolGRPC:
+		// gRPC requires HTTP/2, and allow h2c for http scheme.
+		var protocols http.Protocols
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = &protocols
+		u.Path = strings.TrimSuffix(u.Path, "/") + grpcPath
+	case ProtocolHTTP:
+		if u.Path == unset || u.Path == "/" {
+			u.Path = httpPath
+		}
+	default:
+		return fmt.Errorf("invalid OTLP protocol %v", protocol)
+	}
+
+	return nil
+}
+
+func (e *Exporter) Export(ctx context.Context, metrics []vmomi.Metric) error {
+	if len(metrics) == empty {
+		return nil
+	}
+
+	body := encodeRequest(metrics, e.serverAddress)
+	if e.protocol == ProtocolGRPC {
+		return e.exportGRPC(ctx, body)
+	}
+
+	return e.exportHTTP(ctx, body)
+}
+
+func (e *Exporter) exportHTTP(ctx context.Context, body []byte) error {
+	res, err := e.post(ctx, "application/x-protobuf", body)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("OTLP export failed: %v %v", res.Status, string(msg))
+	}
+
+	_, err = io.Copy(io.Discard, res.Body)
+	return err
+}
+
+func (e *Exporter) exportGRPC(ctx context.Context, body []byte) error {
+	// Length-Prefixed-Message of gRPC over HTTP/2.
+	msg := make([]byte, grpcHeaderSize, grpcHeaderSize+len(body))
+	msg[grpcFlagIndex] = uncompressed
+	binary.BigEndian.PutUint32(msg[grpcLengthIndex:], uint32(len(body)))
+	msg = append(msg, body...)
+
+	res, err := e.post(ctx, "application/grpc", msg)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	// Trailers are available after reading body.
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("OTLP export failed: %v", res.Status)
+	}
+
+	return grpcStatus(res)
+}
+
+func (e *Exporter) post(
+	ctx context.Context,
+	contentType string,
+	body []byte,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	if e.protocol == ProtocolGRPC {
+		req.Header.Set("TE", "trailers")
+	}
+
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	return e.client.Do(req)
+}
+
+func grpcStatus(res *http.Response) error {
+	status := res.Trailer.Get("Grpc-Status")
+	message := res.Trailer.Get("Grpc-Message")
+	if status == unset {
+		// Trailers-Only response is sent as headers.
+		status = res.Header.Get("Grpc-Status")
+		message = res.Header.Get("Grpc-Message")
+	}
+
+	if status != grpcOK {
+		return fmt.Errorf("OTLP export failed: grpc-status %v %v", status, message)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/protowiretest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	serverAddress  = "vcenter.local"
+	sampleValue    = 42
+	singleRequest  = 1
+	http2          = 2
+	sampleInterval = 20
+)
+
+var (
+	sampleTime = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	sampleEntity = vmomi.Entity{
+		ID:   "host-21",
+		Name: "DC0_H0",
+		Type: vmomi.ManagedEntityTypeHostSystem,
+	}
+
+	sampleCounter = vmomi.CounterInfo{
+		ID:          2,
+		Group:       "cpu",
+		Name:        "usage",
+		NameSummary: "CPU usage",
+		Rollup:      "average",
+		Stats:       "rate",
+		Unit:        "percent",
+	}
+)
+
+// startReceiver starts OTLP receiver stub which sends received request to the channel.
+func startReceiver(t *testing.T, protocol string) (string, <-chan []byte) {
+	t.Helper()
+
+	received := make(chan []byte, singleRequest)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Read: %v", err)
+		}
+
+		if protocol == ProtocolGRPC {
+			body = receiveGRPC(t, w, r, body)
+		}
+
+		received <- body
+	})
+
+	server := httptest.NewUnstartedServer(handler)
+	if protocol == ProtocolGRPC {
+		var protocols http.Protocols
+		protocols.SetUnencryptedHTTP2(true)
+		server.Config.Protocols = &protocols
+	}
+
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return server.URL, received
+}
+
+func receiveGRPC(t *testing.T, w http.ResponseWriter, r *http.Request, body []byte) []byte {
+	t.Helper()
+
+	if r.ProtoMajor != http2 || r.URL.Path != grpcPath {
+		t.Errorf("Invalid gRPC request %v %v", r.Proto, r.URL.Path)
+	}
+
+	if len(body) < grpcHeaderSize ||
+		int(binary.BigEndian.Uint32(body[grpcLengthIndex:])) != len(body)-grpcHeaderSize {
+		t.Fatalf("Invalid gRPC message length %v", len(body))
+	}
+
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", grpcOK)
+	return body[grpcHeaderSize:]
+}
+
+func TestExport(t *testing.T) {
+	for _, protocol := range []string{ProtocolHTTP, ProtocolGRPC} {
+		t.Run(protocol, func(t *testing.T) {
+			exportToReceiver(t, protocol)
+		})
+	}
+}
+
+func exportToReceiver(t *testing.T, protocol string) {
+	t.Helper()
+
+	endpoint, received := startReceiver(t, protocol)
+
+	exporter, err := NewExporter(
+		endpoint,
+		WithProtocol(protocol),
+		WithServerAddress(serverAddress),
+	)
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+
+	metrics := []vmomi.Metric{{
+		Entity:    sampleEntity,
+		Counter:   sampleCounter,
+		Instance:  "0",
+		Timestamp: sampleTime,
+		Value:     sampleValue,
+		Interval:  sampleInterval,
+	}}
+
+	if err := exporter.Export(t.Context(), metrics); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	assertRequest(t, <-received)
+}
+
+func assertRequest(t *testing.T, body []byte) {
+	t.Helper()
+
+	request := protowiretest.Decode(t, body)
+	resourceMetrics := request.Messages(requestResourceMetrics)
+	if len(resourceMetrics) != singleRequest {
+		t.Fatalf("Resource metrics %v", len(resourceMetrics))
+	}
+
+	resource := resourceMetrics[0].Message(resourceMetricsResource)
+	assertAttributes(t, resource.Messages(resourceAttributes), map[string]string{
+		AttributeServiceName:   serviceName,
+		AttributeServerAddress: serverAddress,
+		AttributeEntityID:      sampleEntity.ID,
+		AttributeEntityName:    sampleEntity.Name,
+		AttributeEntityType:    string(sampleEntity.Type),
+	})
+
+	metric := resourceMetrics[0].Message(resourceMetricsScope).Message(scopeMetricsMetrics)
+	if name := metric.String(metricName); name != "vmomi.cpu.usage.average" {
+		t.Errorf("Metric name %v", name)
+	}
+
+	if unit := metric.String(metricUnit); unit != "percent" {
+		t.Errorf("Metric unit %v", unit)
+	}
+
+	point := metric.Message(metricGauge).Message(gaugeDataPoints)
+	if value := point.Uint64(numberDataPointAsInt); value != sampleValue {
+		t.Errorf("Gauge value %v", value)
+	}
+
+	if ts := point.Uint64(numberDataPointTime); ts != uint64(sampleTime.UnixNano()) {
+		t.Errorf("Gauge timestamp %v", ts)
+	}
+
+	assertAttributes(t, point.Messages(numberDataPointAttribute), map[string]string{
+		AttributeInstance: "0",
+		AttributeInterval: "20",
+		AttributeStat:     "rate",
+	})
+}
+
+func assertAttributes(
+	t *testing.T,
+	attributes []protowiretest.Message,
+	expected map[string]string,
+) {
+	t.Helper()
+
+	actual := map[string]string{}
+	for _, a := range attributes {
+		actual[a.String(keyValueKey)] = a.Message(keyValueValue).String(anyValueString)
+	}
+
+	if len(actual) != len(expected) {
+		t.Errorf("Attributes %v, expected %v", actual, expected)
+	}
+
+	for k, v := range expected {
+		if actual[k] != v {
+			t.Errorf("Attribute %v is %q, expected %q", k, actual[k], v)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"go.yaml.in/yaml/v4"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+	FormatTable = "table"
+	FormatYAML  = "yaml"
+)
+
+const (
+	jsonIndent     = "  "
+	noPrefix       = ""
+	tableMinWidth  = 0
+	tableTabWidth  = 8
+	tablePadding   = 2
+	tablePadChar   = ' '
+	tableNoFlags   = 0
+	tableSeparator = "\t"
+)
+
+type Column[T any] struct {
+	Name  string
+	Value func(item T) any
+}
+
+func Formats() []string {
+	return []string{
+		FormatYAML,
+		FormatJSON,
+		FormatCSV,
+		FormatTable,
+	}
+}
+
+func Validate(format string) error {
+	if !slices.Contains(Formats(), format) {
+		return fmt.Errorf("invalid output format %v", format)
+	}
+
+	return nil
+}
+
+// Write writes items as list of records which have a field per column.
+func Write[T any](w io.Writer, format string, columns []Column[T], items []T) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, columns, items)
+	case FormatJSON:
+		return WriteJSON(w, toRecords(columns, items))
+	case FormatTable:
+		return writeTable(w, columns, items)
+	case FormatYAML:
+		return WriteYAML(w, toRecords(columns, items))
+	default:
+		return Validate(format)
+	}
+}
+
+func WriteJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent(noPrefix, jsonIndent)
+	return enc.Encode(v)
+}
+
+func WriteYAML(w io.Writer, v any) error {
+	buf, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+func writeCSV[T any](w io.Writer, columns []Column[T], items []T) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(toHeader(columns)); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := cw.Write(toRow(columns, item)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTable[T any](w io.Writer, columns []Column[T], items []T) error {
+	tw := tabwriter.NewWriter(
+		w,
+		tableMinWidth,
+		tableTabWidth,
+		tablePadding,
+		tablePadChar,
+		tableNoFlags,
+	)
+
+	header := toHeader(columns)
+	for i, h := range header {
+		header[i] = strings.ToUpper(h)
+	}
+
+	lines := []string{strings.Join(header, tableSeparator)}
+	for _, item := range items {
+		lines = append(lines, strings.Join(toRow(columns, item), tableSeparator))
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(tw, line); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func toHeader[T any](columns []Column[T]) []string {
+	header := []string{}
+	for _, c := range columns {
+		header = append(header, c.Name)
+	}
+
+	return header
+}
+
+func toRow[T any](columns []Column[T], item T) []string {
+	row := []string{}
+	for _, c := range columns {
+		row = append(row, fmt.Sprint(c.Value(item)))
+	}
+
+	return row
+}
+
+func toRecords[T any](columns []Column[T], items []T) []map[string]any {
+	records := []map[string]any{}
+	for _, item := range items {
+		record := map[string]any{}
+		for _, c := range columns {
+			record[c.Name] = c.Value(item)
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
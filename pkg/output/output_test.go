@@ -0,0 +1,61 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/output"
+)
+
+type item struct {
+	id   int
+	name string
+}
+
+var columns = []output.Column[item]{
+	{Name: "id", Value: func(i item) any { return i.id }},
+	{Name: "name", Value: func(i item) any { return i.name }},
+}
+
+var items = []item{
+	{id: 1, name: "a,b"},
+	{id: 20, name: "c"},
+}
+
+func assertWrite(t *testing.T, format string, expected string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := output.Write(&buf, format, columns, items); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected output\n%v", buf.String())
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{output.FormatCSV, "id,name\n1,\"a,b\"\n20,c\n"},
+		{output.FormatJSON, "[\n  {\n    \"id\": 1,\n    \"name\": \"a,b\"\n  },\n" +
+			"  {\n    \"id\": 20,\n    \"name\": \"c\"\n  }\n]\n"},
+		{output.FormatTable, "ID  NAME\n1   a,b\n20  c\n"},
+		{output.FormatYAML, "- id: 1\n  name: a,b\n- id: 20\n  name: c\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			assertWrite(t, test.format, test.expected)
+		})
+	}
+}
+
+func TestWriteInvalidFormat(t *testing.T) {
+	if err := output.Write(&bytes.Buffer{}, "xml", columns, items); err == nil {
+		t.Error("Accepted invalid format")
+	}
+}
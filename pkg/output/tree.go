@@ -0,0 +1,187 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	FormatDOT  = "dot"
+	FormatText = "text"
+)
+
+const (
+	treeBranch     = "├── "
+	treeLastBranch = "└── "
+	treeIndent     = "│   "
+	treeLastIndent = "    "
+	treeArrow      = "-> "
+	lastOffset     = 1
+)
+
+type treeNode struct {
+	ID        string         `json:"id"                  yaml:"id"`
+	Name      string         `json:"name"                yaml:"name"`
+	Type      string         `json:"type"                yaml:"type"`
+	Relations []treeRelation `json:"relations,omitempty" yaml:"relations,omitempty"`
+	Children  []treeNode     `json:"children,omitempty"  yaml:"children,omitempty"`
+}
+
+type treeRelation struct {
+	ID   string `json:"id"   yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+}
+
+func TreeFormats() []string {
+	return []string{
+		FormatText,
+		FormatYAML,
+		FormatJSON,
+		FormatDOT,
+	}
+}
+
+// WriteTree writes entity trees as indented text, YAML, JSON or Graphviz DOT.
+func WriteTree(w io.Writer, format string, roots []*vmomi.EntityNode) error {
+	switch format {
+	case FormatText:
+		return writeTreeText(w, roots)
+	case FormatYAML:
+		return WriteYAML(w, toTreeNodes(roots))
+	case FormatJSON:
+		return writeTreeJSON(w, roots)
+	case FormatDOT:
+		return writeTreeDOT(w, roots)
+	default:
+		return fmt.Errorf("invalid tree format %v", format)
+	}
+}
+
+func writeTreeText(w io.Writer, roots []*vmomi.EntityNode) error {
+	for _, root := range roots {
+		if _, err := fmt.Fprintln(w, entityLabel(root.Entity)); err != nil {
+			return err
+		}
+
+		if err := writeTreeChildren(w, root, noPrefix); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTreeChildren(w io.Writer, node *vmomi.EntityNode, prefix string) error {
+	if err := writeTreeRelations(w, node, prefix); err != nil {
+		return err
+	}
+
+	for idx, child := range node.Children {
+		branch, indent := treeBranch, treeIndent
+		if idx == len(node.Children)-lastOffset {
+			branch, indent = treeLastBranch, treeLastIndent
+		}
+
+		if err := writeTreeChild(w, child, prefix+branch, prefix+indent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTreeRelations(w io.Writer, node *vmomi.EntityNode, prefix string) error {
+	for _, r := range node.Relations {
+		if _, err := fmt.Fprintln(w, prefix+treeArrow+entityLabel(r)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTreeChild(w io.Writer, node *vmomi.EntityNode, line, indent string) error {
+	if _, err := fmt.Fprintln(w, line+entityLabel(node.Entity)); err != nil {
+		return err
+	}
+
+	return writeTreeChildren(w, node, indent)
+}
+
+func entityLabel(e vmomi.Entity) string {
+	return fmt.Sprintf("%v %v (%v)", e.Type, e.Name, e.ID)
+}
+
+func writeTreeJSON(w io.Writer, roots []*vmomi.EntityNode) error {
+	e := json.NewEncoder(w)
+	e.SetIndent(noPrefix, jsonIndent)
+	return e.Encode(toTreeNodes(roots))
+}
+
+func toTreeNodes(roots []*vmomi.EntityNode) []treeNode {
+	nodes := []treeNode{}
+	for _, root := range roots {
+		nodes = append(nodes, toTreeNode(root))
+	}
+
+	return nodes
+}
+
+func toTreeNode(node *vmomi.EntityNode) treeNode {
+	n := treeNode{
+		ID:        node.Entity.ID,
+		Name:      node.Entity.Name,
+		Type:      string(node.Entity.Type),
+		Relations: []treeRelation{},
+		Children:  []treeNode{},
+	}
+
+	for _, r := range node.Relations {
+		n.Relations = append(n.Relations, treeRelation{
+			ID:   r.ID,
+			Name: r.Name,
+			Type: string(r.Type),
+		})
+	}
+
+	for _, child := range node.Children {
+		n.Children = append(n.Children, toTreeNode(child))
+	}
+
+	return n
+}
+
+func writeTreeDOT(w io.Writer, roots []*vmomi.EntityNode) error {
+	lines := []string{"digraph inventory {"}
+	for _, root := range roots {
+		lines = append(lines, dotNode(root)...)
+	}
+
+	lines = append(lines, "}")
+
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func dotNode(node *vmomi.EntityNode) []string {
+	e := node.Entity
+	lines := []string{
+		fmt.Sprintf("  %q [label=%q];", e.ID, fmt.Sprintf("%v\n%v\n%v", e.Type, e.Name, e.ID)),
+	}
+
+	for _, r := range node.Relations {
+		lines = append(lines, fmt.Sprintf("  %q -> %q [style=dashed];", e.ID, r.ID))
+	}
+
+	for _, child := range node.Children {
+		lines = append(lines, fmt.Sprintf("  %q -> %q;", e.ID, child.Entity.ID))
+		lines = append(lines, dotNode(child)...)
+	}
+
+	return lines
+}
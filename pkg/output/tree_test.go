@@ -0,0 +1,49 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/output"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	clusterCount = 1
+	clusterHosts = 1
+)
+
+func TestWriteTree(t *testing.T) {
+	// Single host cluster because vcsim places cluster VM on random host.
+	sim := vcsimtest.Start(t, vcsimtest.WithCluster(clusterCount, clusterHosts))
+
+	root, err := vmomi.GetEntityTree(sim.Context)
+	if err != nil {
+		t.Fatalf("GetEntityTree: %v", err)
+	}
+
+	clusters := root.Find(func(e vmomi.Entity) bool {
+		return e.Type == vmomi.ManagedEntityTypeClusterComputeResource
+	})
+
+	tests := []struct {
+		format string
+		nodes  []*vmomi.EntityNode
+		golden string
+	}{
+		{output.FormatText, []*vmomi.EntityNode{root}, "testdata/tree_root.golden"},
+		{output.FormatYAML, clusters, "testdata/tree_cluster_yaml.golden"},
+		{output.FormatJSON, clusters, "testdata/tree_cluster_json.golden"},
+		{output.FormatDOT, clusters, "testdata/tree_cluster_dot.golden"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := output.WriteTree(&buf, test.format, test.nodes); err != nil {
+			t.Fatalf("WriteTree(%v): %v", test.format, err)
+		}
+
+		vcsimtest.AssertGolden(t, test.golden, buf.Bytes())
+	}
+}
@@ -0,0 +1,282 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+)
+
+const (
+	backoffFactor         = 2
+	defaultBatchSize      = 1000
+	defaultInitialBackoff = 1 * time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultMaxRetries     = 3
+	defaultTimeout        = 10 * time.Second
+	empty                 = 0
+	labelSeparator        = "\xff"
+	minBatchSize          = 1
+	protocolVersion       = "0.1.0"
+)
+
+// Forget series not written for a while such as deleted virtual machine.
+const staleSeries = 1 * time.Hour
+
+type ClientOptions struct {
+	Headers    map[string]string
+	Timeout    time.Duration
+	BatchSize  int
+	MaxRetries int
+}
+
+type Client struct {
+	url        string
+	headers    map[string]string
+	batchSize  int
+	maxRetries int
+	client     *http.Client
+	lastSent   map[string]sentSeries
+	rock       sync.Mutex
+}
+
+type sentSeries struct {
+	timestamp time.Time
+	seen      time.Time
+}
+
+type recoverableError struct {
+	err error
+}
+
+func (e *recoverableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *recoverableError) Unwrap() error {
+	return e.err
+}
+
+func defaultClientOptions() ClientOptions {
+	return ClientOptions{
+		Headers:    map[string]string{},
+		Timeout:    defaultTimeout,
+		BatchSize:  defaultBatchSize,
+		MaxRetries: defaultMaxRetries,
+	}
+}
+
+func WithHeaders(headers map[string]string) func(o *ClientOptions) {
+	return func(o *ClientOptions) {
+		o.Headers = headers
+	}
+}
+
+func WithTimeout(timeout time.Duration) func(o *ClientOptions) {
+	return func(o *ClientOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithBatchSize(size int) func(o *ClientOptions) {
+	return func(o *ClientOptions) {
+		o.BatchSize = size
+	}
+}
+
+func WithMaxRetries(retries int) func(o *ClientOptions) {
+	return func(o *ClientOptions) {
+		o.MaxRetries = retries
+	}
+}
+
+func NewClient(url string, opts ...func(o *ClientOptions)) *Client {
+	opt := defaultClientOptions()
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return &Client{
+		url:        url,
+		headers:    opt.Headers,
+		batchSize:  max(opt.BatchSize, minBatchSize),
+		maxRetries: max(opt.MaxRetries, empty),
+		client: &http.Client{
+			Timeout: opt.Timeout,
+		},
+		lastSent: map[string]sentSeries{},
+	}
+}
+
+func (c *Client) Write(ctx context.Context, series []Series) error {
+	pending := c.filterUnsent(series)
+
+	for _, batch := range toBatches(pending, c.batchSize) {
+		if err := c.sendWithRetry(ctx, batch); err != nil {
+			return err
+		}
+
+		c.markSent(batch)
+	}
+
+	return nil
+}
+
+func (c *Client) filterUnsent(series []Series) []Series {
+	c.rock.Lock()
+	defer c.rock.Unlock()
+
+	now := time.Now()
+	c.pruneStale(now)
+
+	pending := []Series{}
+	for _, s := range series {
+		key := seriesKey(s.Labels)
+		last := c.lastSent[key]
+		last.seen = now
+		c.lastSent[key] = last
+
+		// Drop samples already sent to avoid out-of-order or duplicated samples.
+		samples := slices.DeleteFunc(slices.Clone(s.Samples), func(sample Sample) bool {
+			return !sample.Timestamp.After(last.timestamp)
+		})
+		if len(samples) == empty {
+			continue
+		}
+
+		slices.SortFunc(samples, func(a, b Sample) int {
+			return a.Timestamp.Compare(b.Timestamp)
+		})
+
+		pending = append(pending, Series{
+			Labels:  s.Labels,
+			Samples: samples,
+		})
+	}
+
+	return pending
+}
+
+func (c *Client) markSent(series []Series) {
+	c.rock.Lock()
+	defer c.rock.Unlock()
+
+	for _, s := range series {
+		key := seriesKey(s.Labels)
+		last := c.lastSent[key]
+		for _, sample := range s.Samples {
+			if sample.Timestamp.After(last.timestamp) {
+				last.timestamp = sample.Timestamp
+			}
+		}
+
+		c.lastSent[key] = last
+	}
+}
+
+func (c *Client) pruneStale(now time.Time) {
+	for key, last := range c.lastSent {
+		if now.Sub(last.seen) > staleSeries {
+			delete(c.lastSent, key)
+		}
+	}
+}
+
+func (c *Client) sendWithRetry(ctx context.Context, series []Series) error {
+	body := s2.EncodeSnappy(nil, encodeWriteRequest(series))
+
+	backoff := defaultInitialBackoff
+	for attempt := empty; ; attempt++ {
+		err := c.send(ctx, body)
+
+		var recoverable *recoverableError
+		if err == nil || attempt >= c.maxRetries || !errors.As(err, &recoverable) {
+			return err
+		}
+
+		slog.DebugContext(ctx, "Retry", "attempt", attempt, "backoff", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*backoffFactor, defaultMaxBackoff)
+	}
+}
+
+func (c *Client) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", protocolVersion)
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return &recoverableError{err: err}
+	}
+
+	defer res.Body.Close()
+
+	msg, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	err = fmt.Errorf("remote write failed: %v %v", res.Status, string(msg))
+	if res.StatusCode >= http.StatusInternalServerError {
+		// Retry on server error only.
+		return &recoverableError{err: err}
+	}
+
+	return err
+}
+
+func toBatches(series []Series, size int) [][]Series {
+	batches := [][]Series{}
+	batch := []Series{}
+	count := empty
+	for _, s := range series {
+		if len(batch) != empty && count+len(s.Samples) > size {
+			batches = append(batches, batch)
+			batch = []Series{}
+			count = empty
+		}
+
+		batch = append(batch, s)
+		count += len(s.Samples)
+	}
+
+	if len(batch) != empty {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+func seriesKey(labels []Label) string {
+	parts := []string{}
+	for _, l := range labels {
+		parts = append(parts, l.Name, l.Value)
+	}
+
+	return strings.Join(parts, labelSeparator)
+}
@@ -0,0 +1,213 @@
+package remotewrite
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/protowiretest"
+)
+
+const (
+	firstValue  = 1.5
+	secondValue = 2.5
+	noRetry     = 0
+	oneRetry    = 1
+	nextStatus  = 1
+	oneRequest  = 1
+	writeFailed = "Write: %v"
+)
+
+var (
+	firstTime  = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	secondTime = firstTime.Add(20 * time.Second)
+
+	seriesLabels = []Label{
+		{Name: "__name__", Value: "cpu_usage_average"},
+		{Name: "entity_name", Value: "DC0_H0"},
+	}
+)
+
+type receivedSample struct {
+	value     float64
+	timestamp int64
+}
+
+// receiver is remote-write receiver stub which responds status in order,
+// and responds 204 after that.
+type receiver struct {
+	t        *testing.T
+	statuses []int
+	requests [][]receivedSample
+	rock     sync.Mutex
+}
+
+func startReceiver(t *testing.T, statuses ...int) (*receiver, string) {
+	t.Helper()
+
+	r := &receiver{t: t, statuses: statuses, requests: [][]receivedSample{}}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	return r, server.URL
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.rock.Lock()
+	defer r.rock.Unlock()
+
+	if req.Header.Get("Content-Encoding") != "snappy" ||
+		req.Header.Get("X-Prometheus-Remote-Write-Version") != protocolVersion {
+		r.t.Errorf("Invalid headers %v", req.Header)
+	}
+
+	compressed, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.t.Errorf("Read: %v", err)
+	}
+
+	body, err := s2.Decode(nil, compressed)
+	if err != nil {
+		r.t.Errorf("Decode snappy: %v", err)
+	}
+
+	r.requests = append(r.requests, r.decode(body))
+
+	status := http.StatusNoContent
+	if len(r.statuses) != empty {
+		status, r.statuses = r.statuses[empty], r.statuses[nextStatus:]
+	}
+
+	w.WriteHeader(status)
+}
+
+func (r *receiver) decode(body []byte) []receivedSample {
+	samples := []receivedSample{}
+	for _, ts := range protowiretest.Decode(r.t, body).Messages(writeRequestTimeseries) {
+		r.assertLabels(ts.Messages(timeSeriesLabels))
+
+		for _, s := range ts.Messages(timeSeriesSamples) {
+			samples = append(samples, receivedSample{
+				value:     math.Float64frombits(s.Uint64(sampleValue)),
+				timestamp: int64(s.Uint64(sampleTimestamp)),
+			})
+		}
+	}
+
+	return samples
+}
+
+func (r *receiver) assertLabels(labels []protowiretest.Message) {
+	actual := []Label{}
+	for _, l := range labels {
+		actual = append(actual, Label{Name: l.String(labelName), Value: l.String(labelValue)})
+	}
+
+	if !slices.Equal(actual, seriesLabels) {
+		r.t.Errorf("Labels %v, expected %v", actual, seriesLabels)
+	}
+}
+
+func (r *receiver) received() [][]receivedSample {
+	r.rock.Lock()
+	defer r.rock.Unlock()
+
+	return r.requests
+}
+
+func newSeries(samples ...Sample) []Series {
+	return []Series{{Labels: seriesLabels, Samples: samples}}
+}
+
+func TestWriteSkipsSentSamples(t *testing.T) {
+	r, url := startReceiver(t)
+	c := NewClient(url)
+
+	first := Sample{Value: firstValue, Timestamp: firstTime}
+	second := Sample{Value: secondValue, Timestamp: secondTime}
+
+	for _, series := range [][]Series{
+		newSeries(first),
+		newSeries(second, first),
+		newSeries(first, second),
+	} {
+		if err := c.Write(t.Context(), series); err != nil {
+			t.Fatalf(writeFailed, err)
+		}
+	}
+
+	expected := [][]receivedSample{
+		{{value: firstValue, timestamp: firstTime.UnixMilli()}},
+		{{value: secondValue, timestamp: secondTime.UnixMilli()}},
+	}
+
+	assertReceived(t, r.received(), expected)
+}
+
+func TestWriteRetriesServerError(t *testing.T) {
+	r, url := startReceiver(t, http.StatusServiceUnavailable)
+	c := NewClient(url, WithMaxRetries(oneRetry))
+
+	sample := Sample{Value: firstValue, Timestamp: firstTime}
+	if err := c.Write(t.Context(), newSeries(sample)); err != nil {
+		t.Fatalf(writeFailed, err)
+	}
+
+	sent := []receivedSample{{value: firstValue, timestamp: firstTime.UnixMilli()}}
+	assertReceived(t, r.received(), [][]receivedSample{sent, sent})
+}
+
+func TestWriteNotRetriesClientError(t *testing.T) {
+	r, url := startReceiver(t, http.StatusBadRequest)
+	c := NewClient(url, WithMaxRetries(oneRetry))
+
+	sample := Sample{Value: firstValue, Timestamp: firstTime}
+	if err := c.Write(t.Context(), newSeries(sample)); err == nil {
+		t.Fatal("Write succeeded on client error")
+	}
+
+	if len(r.received()) != oneRequest {
+		t.Errorf("Requests %v", len(r.received()))
+	}
+
+	// Samples are sent again because they are not accepted.
+	if err := c.Write(t.Context(), newSeries(sample)); err != nil {
+		t.Fatalf(writeFailed, err)
+	}
+}
+
+func TestWritePrunesStaleSeries(t *testing.T) {
+	_, url := startReceiver(t)
+	c := NewClient(url, WithMaxRetries(noRetry))
+
+	sample := Sample{Value: firstValue, Timestamp: firstTime}
+	if err := c.Write(t.Context(), newSeries(sample)); err != nil {
+		t.Fatalf(writeFailed, err)
+	}
+
+	key := seriesKey(seriesLabels)
+	c.lastSent[key] = sentSeries{timestamp: firstTime, seen: time.Now().Add(-staleSeries * 2)}
+
+	if err := c.Write(t.Context(), []Series{}); err != nil {
+		t.Fatalf(writeFailed, err)
+	}
+
+	if _, ok := c.lastSent[key]; ok {
+		t.Errorf("Not pruned %v", key)
+	}
+}
+
+func assertReceived(t *testing.T, actual [][]receivedSample, expected [][]receivedSample) {
+	t.Helper()
+
+	if !slices.EqualFunc(actual, expected, slices.Equal) {
+		t.Errorf("Requests %v, expected %v", actual, expected)
+	}
+}
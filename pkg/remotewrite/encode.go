@@ -0,0 +1,66 @@
+package remotewrite
+
+import (
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	pwx "github.com/9506hqwy/vmomi-exporter/pkg/protowireex"
+)
+
+// Field numbers in prometheus/prompb/remote.proto and types.proto.
+const (
+	writeRequestTimeseries = protowire.Number(1)
+	timeSeriesLabels       = protowire.Number(1)
+	timeSeriesSamples      = protowire.Number(2)
+	labelName              = protowire.Number(1)
+	labelValue             = protowire.Number(2)
+	sampleValue            = protowire.Number(1)
+	sampleTimestamp        = protowire.Number(2)
+)
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+type Sample struct {
+	Value     float64
+	Timestamp time.Time
+}
+
+type Series struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+func encodeWriteRequest(series []Series) []byte {
+	var b []byte
+	for _, s := range series {
+		b = pwx.AppendMessage(b, writeRequestTimeseries, encodeTimeSeries(s))
+	}
+
+	return b
+}
+
+func encodeTimeSeries(s Series) []byte {
+	var b []byte
+	for _, l := range s.Labels {
+		var label []byte
+		label = pwx.AppendString(label, labelName, l.Name)
+		label = pwx.AppendString(label, labelValue, l.Value)
+		b = pwx.AppendMessage(b, timeSeriesLabels, label)
+	}
+
+	for _, sample := range s.Samples {
+		var v []byte
+		v = protowire.AppendTag(v, sampleValue, protowire.Fixed64Type)
+		v = protowire.AppendFixed64(v, math.Float64bits(sample.Value))
+		v = protowire.AppendTag(v, sampleTimestamp, protowire.VarintType)
+		v = protowire.AppendVarint(v, uint64(sample.Timestamp.UnixMilli()))
+		b = pwx.AppendMessage(b, timeSeriesSamples, v)
+	}
+
+	return b
+}
@@ -0,0 +1,116 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	defaultGraphitePrefix = "vmomi"
+	networkTCP            = "tcp"
+	pathSeparator         = "."
+)
+
+// Replace characters not allowed in a node of the metric path.
+var nodeEscaper = strings.NewReplacer(pathSeparator, "_", " ", "_", "/", "_")
+
+type GraphiteOptions struct {
+	Prefix  string
+	Timeout time.Duration
+}
+
+type Graphite struct {
+	address string
+	prefix  string
+	timeout time.Duration
+}
+
+func defaultGraphiteOptions() GraphiteOptions {
+	return GraphiteOptions{
+		Prefix:  defaultGraphitePrefix,
+		Timeout: defaultTimeout,
+	}
+}
+
+func WithPrefix(prefix string) func(o *GraphiteOptions) {
+	return func(o *GraphiteOptions) {
+		o.Prefix = prefix
+	}
+}
+
+func WithGraphiteTimeout(timeout time.Duration) func(o *GraphiteOptions) {
+	return func(o *GraphiteOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// NewGraphite writes plaintext protocol by TCP to address such as `localhost:2003`.
+func NewGraphite(address string, opts ...func(o *GraphiteOptions)) *Graphite {
+	opt := defaultGraphiteOptions()
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return &Graphite{
+		address: address,
+		prefix:  opt.Prefix,
+		timeout: opt.Timeout,
+	}
+}
+
+func (s *Graphite) Export(ctx context.Context, metrics []vmomi.Metric) error {
+	if len(metrics) == empty {
+		return nil
+	}
+
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, networkTCP, s.address)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(deadlineOf(ctx, s.timeout)); err != nil {
+		return err
+	}
+
+	lines := []string{}
+	for _, m := range metrics {
+		line := fmt.Sprintf("%v %v %v\n", ToPath(s.prefix, m), m.Value, m.Timestamp.Unix())
+		lines = append(lines, line)
+	}
+
+	_, err = conn.Write([]byte(strings.Join(lines, unset)))
+	return err
+}
+
+func ToPath(prefix string, m vmomi.Metric) string {
+	nodes := []string{
+		string(m.Entity.Type),
+		m.Entity.Name,
+		m.Counter.Group,
+		m.Counter.Name,
+		m.Counter.Rollup,
+	}
+
+	if m.Instance != unset {
+		nodes = append(nodes, m.Instance)
+	}
+
+	path := []string{}
+	if prefix != unset {
+		path = append(path, prefix)
+	}
+
+	for _, n := range nodes {
+		path = append(path, nodeEscaper.Replace(n))
+	}
+
+	return strings.Join(path, pathSeparator)
+}
@@ -0,0 +1,49 @@
+package sink_test
+
+import (
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/sink"
+)
+
+func TestToPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		entity   string
+		instance string
+		expected string
+	}{
+		{
+			"omit empty instance",
+			"vmomi",
+			"DC0_H0",
+			noInstance,
+			"vmomi.HostSystem.DC0_H0.cpu.usage.average",
+		},
+		{"omit empty prefix", "", "DC0_H0", "0", "HostSystem.DC0_H0.cpu.usage.average.0"},
+		{
+			"replace dot in node",
+			"vmomi.prod",
+			"esxi01.domain.local",
+			noInstance,
+			"vmomi.prod.HostSystem.esxi01_domain_local.cpu.usage.average",
+		},
+		{
+			"replace space and slash in node",
+			"vmomi",
+			"esxi 01",
+			"/vmfs/volumes/ds1",
+			"vmomi.HostSystem.esxi_01.cpu.usage.average._vmfs_volumes_ds1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := newMetric(test.entity, cpuGroup, test.instance)
+			if path := sink.ToPath(test.prefix, m); path != test.expected {
+				t.Errorf("Unexpected path %v", path)
+			}
+		})
+	}
+}
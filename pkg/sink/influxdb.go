@@ -0,0 +1,228 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	TagEntityID   = "entity_id"
+	TagEntityName = "entity_name"
+	TagEntityType = "entity_type"
+	TagInstance   = "instance"
+	TagInterval   = "interval"
+)
+
+const (
+	// Same as default payload size of Telegraf to avoid fragmentation.
+	defaultMaxPayloadSize = 512
+	measurementPrefix     = "vmomi_"
+	schemeUDP             = "udp"
+)
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+type InfluxDBOptions struct {
+	Headers        map[string]string
+	Timeout        time.Duration
+	MaxPayloadSize int
+}
+
+type InfluxDB struct {
+	url            *url.URL
+	headers        map[string]string
+	timeout        time.Duration
+	maxPayloadSize int
+	client         *http.Client
+}
+
+func defaultInfluxDBOptions() InfluxDBOptions {
+	return InfluxDBOptions{
+		Headers:        map[string]string{},
+		Timeout:        defaultTimeout,
+		MaxPayloadSize: defaultMaxPayloadSize,
+	}
+}
+
+func WithInfluxDBHeaders(headers map[string]string) func(o *InfluxDBOptions) {
+	return func(o *InfluxDBOptions) {
+		o.Headers = headers
+	}
+}
+
+func WithInfluxDBTimeout(timeout time.Duration) func(o *InfluxDBOptions) {
+	return func(o *InfluxDBOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithMaxPayloadSize(size int) func(o *InfluxDBOptions) {
+	return func(o *InfluxDBOptions) {
+		o.MaxPayloadSize = size
+	}
+}
+
+// NewInfluxDB writes by HTTP to write API URL such as `http://localhost:8086/write?db=vmware`,
+// or by UDP to URL such as `udp://localhost:8089`.
+func NewInfluxDB(rawURL string, opts ...func(o *InfluxDBOptions)) (*InfluxDB, error) {
+	opt := defaultInfluxDBOptions()
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https", schemeUDP:
+	default:
+		return nil, fmt.Errorf("invalid InfluxDB URL scheme %v", u.Scheme)
+	}
+
+	return &InfluxDB{
+		url:            u,
+		headers:        opt.Headers,
+		timeout:        opt.Timeout,
+		maxPayloadSize: opt.MaxPayloadSize,
+		client: &http.Client{
+			Timeout: opt.Timeout,
+		},
+	}, nil
+}
+
+func (s *InfluxDB) Export(ctx context.Context, metrics []vmomi.Metric) error {
+	if len(metrics) == empty {
+		return nil
+	}
+
+	lines := []string{}
+	for _, m := range metrics {
+		lines = append(lines, ToLine(m))
+	}
+
+	if s.url.Scheme == schemeUDP {
+		return s.exportUDP(ctx, lines)
+	}
+
+	return s.exportHTTP(ctx, lines)
+}
+
+func (s *InfluxDB) exportHTTP(ctx context.Context, lines []string) error {
+	body := strings.Join(lines, unset)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		s.url.String(),
+		bytes.NewBufferString(body),
+	)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	msg, _ := io.ReadAll(res.Body)
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("InfluxDB write failed: %v %v", res.Status, string(msg))
+	}
+
+	return nil
+}
+
+func (s *InfluxDB) exportUDP(ctx context.Context, lines []string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, schemeUDP, s.url.Host)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(deadlineOf(ctx, s.timeout)); err != nil {
+		return err
+	}
+
+	for _, payload := range toPayloads(lines, s.maxPayloadSize) {
+		if _, err := conn.Write(payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func toPayloads(lines []string, size int) [][]byte {
+	payloads := [][]byte{}
+	payload := []byte{}
+	for _, line := range lines {
+		// A line larger than size is sent alone.
+		if len(payload) != empty && len(payload)+len(line) > size {
+			payloads = append(payloads, payload)
+			payload = []byte{}
+		}
+
+		payload = append(payload, line...)
+	}
+
+	if len(payload) != empty {
+		payloads = append(payloads, payload)
+	}
+
+	return payloads
+}
+
+func ToLine(m vmomi.Metric) string {
+	tags := map[string]string{
+		TagEntityID:   m.Entity.ID,
+		TagEntityName: m.Entity.Name,
+		TagEntityType: string(m.Entity.Type),
+		TagInstance:   m.Instance,
+		TagInterval:   fmt.Sprintf("%v", m.Interval),
+	}
+
+	series := []string{measurementEscaper.Replace(measurementPrefix + m.Counter.Group)}
+
+	// Sort tags by key for write performance, and omit empty tag because it is invalid.
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		if tags[k] == unset {
+			continue
+		}
+
+		series = append(series, keyEscaper.Replace(k)+"="+keyEscaper.Replace(tags[k]))
+	}
+
+	field := keyEscaper.Replace(fmt.Sprintf("%v_%v", m.Counter.Name, m.Counter.Rollup))
+	return fmt.Sprintf(
+		"%v %v=%vi %v\n",
+		strings.Join(series, ","),
+		field,
+		m.Value,
+		m.Timestamp.UnixNano(),
+	)
+}
@@ -0,0 +1,128 @@
+package sink_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/sink"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	sampleValue    = 42
+	sampleInterval = 20
+	datagramSize   = 1024
+	cpuGroup       = "cpu"
+	noInstance     = ""
+	// Lines of the same length fit in a payload.
+	linesPerPayload = 2
+)
+
+var sampleTime = time.Unix(1767322800, 0)
+
+func newMetric(name string, group string, instance string) vmomi.Metric {
+	return vmomi.Metric{
+		Entity: vmomi.Entity{
+			ID:   "host-21",
+			Name: name,
+			Type: vmomi.ManagedEntityTypeHostSystem,
+		},
+		Counter: vmomi.CounterInfo{
+			Group:  group,
+			Name:   "usage",
+			Rollup: "average",
+		},
+		Instance:  instance,
+		Timestamp: sampleTime,
+		Value:     sampleValue,
+		Interval:  sampleInterval,
+	}
+}
+
+func TestToLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		metric   vmomi.Metric
+		expected string
+	}{
+		{
+			"omit empty instance",
+			newMetric("DC0_H0", cpuGroup, noInstance),
+			"vmomi_cpu,entity_id=host-21,entity_name=DC0_H0,entity_type=HostSystem,interval=20" +
+				" usage_average=42i 1767322800000000000\n",
+		},
+		{
+			"escape tag value",
+			newMetric("esxi 1,a=b", cpuGroup, "vmhba0"),
+			`vmomi_cpu,entity_id=host-21,entity_name=esxi\ 1\,a\=b,entity_type=HostSystem,` +
+				"instance=vmhba0,interval=20 usage_average=42i 1767322800000000000\n",
+		},
+		{
+			"escape measurement",
+			newMetric("DC0_H0", "a b,c=d", "0"),
+			`vmomi_a\ b\,c=d,entity_id=host-21,entity_name=DC0_H0,entity_type=HostSystem,` +
+				"instance=0,interval=20 usage_average=42i 1767322800000000000\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if line := sink.ToLine(test.metric); line != test.expected {
+				t.Errorf("Unexpected line\n%v", line)
+			}
+		})
+	}
+}
+
+func TestInfluxDBUDPPayload(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	defer conn.Close()
+
+	metrics := []vmomi.Metric{
+		newMetric("a", cpuGroup, noInstance),
+		newMetric("b", cpuGroup, noInstance),
+		newMetric("c", cpuGroup, noInstance),
+	}
+
+	size := len(sink.ToLine(metrics[0])) * linesPerPayload
+	s, err := sink.NewInfluxDB("udp://"+conn.LocalAddr().String(), sink.WithMaxPayloadSize(size))
+	if err != nil {
+		t.Fatalf("NewInfluxDB: %v", err)
+	}
+
+	if err := s.Export(t.Context(), metrics); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	expected := []string{
+		sink.ToLine(metrics[0]) + sink.ToLine(metrics[1]),
+		sink.ToLine(metrics[2]),
+	}
+
+	for _, e := range expected {
+		if payload := readDatagram(t, conn); payload != e {
+			t.Errorf("Unexpected payload\n%v", payload)
+		}
+	}
+}
+
+func readDatagram(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("SetReadDeadline: %v", err)
+	}
+
+	buf := make([]byte, datagramSize)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+
+	return string(buf[:n])
+}
@@ -0,0 +1,27 @@
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	empty          = 0
+	unset          = ""
+)
+
+type Sink interface {
+	Export(ctx context.Context, metrics []vmomi.Metric) error
+}
+
+func deadlineOf(ctx context.Context, timeout time.Duration) time.Time {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+
+	return deadline
+}
@@ -0,0 +1,371 @@
+package vmomi
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	px "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/propertyex"
+)
+
+// AggregateTypeVCenter aggregates all entities under the roots into the vCenter.
+const AggregateTypeVCenter = ManagedEntityType("vCenter")
+
+const (
+	AggregateAvg = "avg"
+	AggregateMax = "max"
+	AggregateSum = "sum"
+)
+
+const (
+	namePath        = "name"
+	parentPath      = "parent"
+	runtimeHostPath = "runtime.host"
+)
+
+type AggregateSpec struct {
+	Type      ManagedEntityType
+	Functions []string
+}
+
+type AggregateMetric struct {
+	Entity     Entity
+	SourceType ManagedEntityType
+	Counter    CounterInfo
+	Function   string
+	Interval   int32
+	Timestamp  time.Time
+	Value      float64
+}
+
+type QueryOptions struct {
+	Aggregates []AggregateSpec
+	Excludes   []RootSpec
+}
+
+// hierarchy holds parents of entities retrieved with the query entities.
+type hierarchy struct {
+	nodes   map[types.ManagedObjectReference]hierarchyNode
+	vcenter Entity
+}
+
+type hierarchyNode struct {
+	entity Entity
+	parent *types.ManagedObjectReference
+}
+
+type aggregateKey struct {
+	parent     Entity
+	sourceType ManagedEntityType
+	counterID  int32
+	interval   int32
+}
+
+type aggregateGroup struct {
+	counter   CounterInfo
+	count     int
+	sum       int64
+	max       int64
+	timestamp time.Time
+}
+
+func defaultQueryOptions() QueryOptions {
+	return QueryOptions{
+		Aggregates: []AggregateSpec{},
+		Excludes:   []RootSpec{},
+	}
+}
+
+func toQueryOptions(opts []func(o *QueryOptions)) QueryOptions {
+	opt := defaultQueryOptions()
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return opt
+}
+
+func WithAggregates(aggregates []AggregateSpec) func(o *QueryOptions) {
+	return func(o *QueryOptions) {
+		o.Aggregates = aggregates
+	}
+}
+
+// WithExcludes drops entities selected by specs and their descendants from query.
+func WithExcludes(excludes []RootSpec) func(o *QueryOptions) {
+	return func(o *QueryOptions) {
+		o.Excludes = excludes
+	}
+}
+
+func AggregateFunctions() []string {
+	return []string{
+		AggregateAvg,
+		AggregateMax,
+		AggregateSum,
+	}
+}
+
+func AggregateTypes() []ManagedEntityType {
+	return []ManagedEntityType{
+		ManagedEntityTypeClusterComputeResource,
+		ManagedEntityTypeComputeResource,
+		ManagedEntityTypeDatacenter,
+		ManagedEntityTypeHostSystem,
+		AggregateTypeVCenter,
+	}
+}
+
+func ValidateAggregate(spec AggregateSpec) error {
+	if !slices.Contains(AggregateTypes(), spec.Type) {
+		return fmt.Errorf("invalid aggregate type %v", spec.Type)
+	}
+
+	for _, f := range spec.Functions {
+		if !slices.Contains(AggregateFunctions(), f) {
+			return fmt.Errorf("invalid aggregate function %v", f)
+		}
+	}
+
+	return nil
+}
+
+func queryAggregates(
+	ctx context.Context,
+	metrics []Metric,
+	h *hierarchy,
+	specs []AggregateSpec,
+) []AggregateMetric {
+	aggregates := []AggregateMetric{}
+	if h == nil {
+		return aggregates
+	}
+
+	started := time.Now()
+	for _, spec := range specs {
+		aggregates = append(aggregates, aggregateMetrics(metrics, h, spec)...)
+	}
+
+	ObservePhase(ctx, PhaseConversion, started)
+
+	return aggregates
+}
+
+// getEntitiesWithHierarchy retrieves parents of entities in the same traversal as entities
+// to derive members of aggregates without retrieving whole inventory.
+// Parents above the roots are not retrieved.
+func getEntitiesWithHierarchy(
+	ctx context.Context,
+	c *vim25.Client,
+	roots []types.ManagedObjectReference,
+	moTypes []string,
+	withRoot bool,
+) (*[]mo.ManagedEntity, *hierarchy, error) {
+	defer ObservePhase(ctx, PhaseInventory, time.Now())
+
+	objects, err := px.RetrieveWithPathSet(ctx, c, roots, hierarchyPathSets(moTypes), withRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h := hierarchy{
+		nodes:   map[types.ManagedObjectReference]hierarchyNode{},
+		vcenter: toVCenterEntity(c),
+	}
+
+	entities := []mo.ManagedEntity{}
+	for _, obj := range objects {
+		h.nodes[obj.Obj] = toHierarchyNode(obj)
+		if !slices.Contains(moTypes, obj.Obj.Type) {
+			continue
+		}
+
+		entity, err := loadManagedObject(obj)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		entities = append(entities, *entity)
+	}
+
+	return &entities, &h, nil
+}
+
+func hierarchyPathSets(moTypes []string) map[string][]string {
+	parentPathSet := []string{namePath, parentPath}
+	pathSets := map[string][]string{
+		string(ManagedEntityTypeClusterComputeResource): parentPathSet,
+		string(ManagedEntityTypeComputeResource):        parentPathSet,
+		string(ManagedEntityTypeDatacenter):             parentPathSet,
+		string(ManagedEntityTypeFolder):                 parentPathSet,
+		string(ManagedEntityTypeHostSystem):             parentPathSet,
+		// Virtual machine belongs to cluster through running host.
+		string(ManagedEntityTypeVirtualMachine): {namePath, runtimeHostPath},
+	}
+
+	for _, moType := range moTypes {
+		if _, ok := pathSets[moType]; !ok {
+			pathSets[moType] = []string{namePath}
+		}
+	}
+
+	return pathSets
+}
+
+func toVCenterEntity(c *vim25.Client) Entity {
+	return Entity{
+		ID:   c.ServiceContent.About.InstanceUuid,
+		Name: c.URL().Hostname(),
+		Type: AggregateTypeVCenter,
+	}
+}
+
+func toHierarchyNode(obj types.ObjectContent) hierarchyNode {
+	node := hierarchyNode{
+		entity: Entity{
+			ID:   obj.Obj.Value,
+			Name: "",
+			Type: ManagedEntityType(obj.Obj.Type),
+		},
+		parent: nil,
+	}
+
+	for _, prop := range obj.PropSet {
+		switch v := prop.Val.(type) {
+		case string:
+			node.entity.Name = v
+		case types.ManagedObjectReference:
+			node.parent = &v
+		}
+	}
+
+	return node
+}
+
+func (h *hierarchy) findAncestor(entity Entity, moType ManagedEntityType) *Entity {
+	if moType == AggregateTypeVCenter {
+		return &h.vcenter
+	}
+
+	ref := types.ManagedObjectReference{
+		Type:  string(entity.Type),
+		Value: entity.ID,
+	}
+
+	node, ok := h.nodes[ref]
+	for ok && node.parent != nil {
+		node, ok = h.nodes[*node.parent]
+		if ok && node.entity.Type == moType {
+			return &node.entity
+		}
+	}
+
+	return nil
+}
+
+func aggregateMetrics(
+	metrics []Metric,
+	h *hierarchy,
+	spec AggregateSpec,
+) []AggregateMetric {
+	functions := spec.Functions
+	if len(functions) == empty {
+		functions = AggregateFunctions()
+	}
+
+	aggregates := []AggregateMetric{}
+	for key, group := range groupMetrics(metrics, h, spec.Type) {
+		for _, f := range functions {
+			aggregates = append(aggregates, toAggregateMetric(key, group, f))
+		}
+	}
+
+	return aggregates
+}
+
+func groupMetrics(
+	metrics []Metric,
+	h *hierarchy,
+	moType ManagedEntityType,
+) map[aggregateKey]*aggregateGroup {
+	groups := map[aggregateKey]*aggregateGroup{}
+	for _, m := range metrics {
+		if !isAggregateSource(m) {
+			continue
+		}
+
+		parent := h.findAncestor(m.Entity, moType)
+		if parent == nil {
+			continue
+		}
+
+		key := aggregateKey{
+			parent:     *parent,
+			sourceType: m.Entity.Type,
+			counterID:  m.Counter.ID,
+			interval:   m.Interval,
+		}
+
+		addAggregateGroup(groups, key, m)
+	}
+
+	return groups
+}
+
+func isAggregateSource(m Metric) bool {
+	// Aggregate only entity total because instances are not comparable across entities.
+	if m.Instance != "" {
+		return false
+	}
+
+	return m.Entity.Type == ManagedEntityTypeHostSystem ||
+		m.Entity.Type == ManagedEntityTypeVirtualMachine
+}
+
+func addAggregateGroup(groups map[aggregateKey]*aggregateGroup, key aggregateKey, m Metric) {
+	group, ok := groups[key]
+	if !ok {
+		group = &aggregateGroup{
+			counter:   m.Counter,
+			count:     empty,
+			sum:       int64(empty),
+			max:       m.Value,
+			timestamp: m.Timestamp,
+		}
+		groups[key] = group
+	}
+
+	group.count++
+	group.sum += m.Value
+	group.max = max(group.max, m.Value)
+	if m.Timestamp.After(group.timestamp) {
+		group.timestamp = m.Timestamp
+	}
+}
+
+func toAggregateMetric(key aggregateKey, group *aggregateGroup, function string) AggregateMetric {
+	var value float64
+	switch function {
+	case AggregateAvg:
+		value = float64(group.sum) / float64(group.count)
+	case AggregateMax:
+		value = float64(group.max)
+	default:
+		value = float64(group.sum)
+	}
+
+	return AggregateMetric{
+		Entity:     key.parent,
+		SourceType: key.sourceType,
+		Counter:    group.counter,
+		Function:   function,
+		Interval:   key.interval,
+		Timestamp:  group.timestamp,
+		Value:      value,
+	}
+}
@@ -0,0 +1,113 @@
+package vmomi
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
+)
+
+// Sample some entities of each type because powered-off VM has no available counters.
+const availableSamples = 3
+
+// GetAvailableCounterIDs returns counters which are available on
+// sample entities of each type under roots.
+func GetAvailableCounterIDs(
+	ctx context.Context,
+	rootEntities *[]Entity,
+	entityTypes []ManagedEntityType,
+) ([]int32, error) {
+	c, err := login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer logout(ctx, c)
+
+	p, err := getPerformanceManager(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	moTypes := []string{}
+	for _, t := range entityTypes {
+		moTypes = append(moTypes, string(t))
+	}
+
+	roots := toRootManagedObjectReference(c, rootEntities)
+	entities, err := getEntities(ctx, c, roots, moTypes, rootEntities != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pm := performance.NewManager(c)
+	ids, err := queryAvailableCounterIDs(ctx, pm, p.HistoricalInterval, sampleEntities(entities))
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+func sampleEntities(entities *[]mo.ManagedEntity) []mo.ManagedEntity {
+	samples := []mo.ManagedEntity{}
+	sampled := map[string]int{}
+	for _, entity := range *entities {
+		moType := entity.Reference().Type
+		if sampled[moType] < availableSamples {
+			sampled[moType]++
+			samples = append(samples, entity)
+		}
+	}
+
+	return samples
+}
+
+func queryAvailableCounterIDs(
+	ctx context.Context,
+	pm *performance.Manager,
+	intervalIDs []types.PerfInterval,
+	entities []mo.ManagedEntity,
+) ([]int32, error) {
+	defer ObservePhase(ctx, PhaseAvailableMetric, time.Now())
+
+	ids := []int32{}
+	intervalIDCache := map[string]IntervalID{}
+	for _, entity := range entities {
+		object := findQueryObject(nil, entity.Reference().Type)
+		intervalID := findIntervalID(ctx, pm, intervalIDs, intervalIDCache, entity, object)
+		if intervalID == nil {
+			// Not support statistics or configured interval.
+			continue
+		}
+
+		metrics, err := sx.ExecCallAPI(
+			ctx,
+			func(cctx context.Context) (performance.MetricList, error) {
+				return pm.AvailableMetric(cctx, entity.Reference(), intervalID.ID)
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, toCounterIDs(metrics)...)
+	}
+
+	return ids, nil
+}
+
+func toCounterIDs(metrics performance.MetricList) []int32 {
+	ids := []int32{}
+	for _, m := range metrics {
+		ids = append(ids, m.CounterId)
+	}
+
+	return ids
+}
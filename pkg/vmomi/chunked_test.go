@@ -0,0 +1,112 @@
+package vmomi
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+)
+
+const (
+	failedChunks   = 2
+	failedSpecs    = 3
+	failedEntities = 2
+	host1          = "host-1"
+	host2          = "host-2"
+	host3          = "host-3"
+)
+
+var errChunk = errors.New("chunk failed")
+
+func sendChunks(chunks ...chunkedBasePerfEntityMetricBase) <-chan chunkedBasePerfEntityMetricBase {
+	ch := make(chan chunkedBasePerfEntityMetricBase, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+
+	close(ch)
+	return ch
+}
+
+func succeededChunk(specs ...types.PerfQuerySpec) chunkedBasePerfEntityMetricBase {
+	metrics := []types.BasePerfEntityMetricBase{}
+	for _, s := range specs {
+		metrics = append(metrics, &types.PerfEntityMetric{
+			PerfEntityMetricBase: types.PerfEntityMetricBase{Entity: s.Entity},
+		})
+	}
+
+	return chunkedBasePerfEntityMetricBase{Specs: &specs, Metrics: &metrics, Err: nil}
+}
+
+func failedChunk(specs ...types.PerfQuerySpec) chunkedBasePerfEntityMetricBase {
+	return chunkedBasePerfEntityMetricBase{Specs: &specs, Metrics: nil, Err: errChunk}
+}
+
+func TestMergeChunkedPartial(t *testing.T) {
+	ctx := context.WithValue(t.Context(), flag.TargetPartialResultsKey{}, true)
+
+	// host-2 is split into two failed chunks by metric chunk size.
+	ch := sendChunks(
+		succeededChunk(newSpec(host1, twoMetrics)),
+		failedChunk(newSpec(host2, twoMetrics), newSpec(host3, oneMetric)),
+		failedChunk(newSpec(host2, oneMetric)),
+	)
+
+	result, err := mergeChunked(ctx, ch)
+	if err != nil {
+		t.Fatalf("mergeChunked: %v", err)
+	}
+
+	if len(result.Metrics) != oneEntity || result.FailedChunks != failedChunks {
+		t.Errorf("Metrics %v, FailedChunks %v", len(result.Metrics), result.FailedChunks)
+	}
+
+	if len(result.FailedSpecs) != failedSpecs {
+		t.Errorf("FailedSpecs %v", result.FailedSpecs)
+	}
+
+	failed := toEntitiesFromSpecs(&[]mo.ManagedEntity{}, result.FailedSpecs)
+	if len(failed) != failedEntities {
+		t.Errorf("FailedEntities %v", failed)
+	}
+}
+
+func TestMergeChunkedFailed(t *testing.T) {
+	partial := context.WithValue(t.Context(), flag.TargetPartialResultsKey{}, true)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		ch   <-chan chunkedBasePerfEntityMetricBase
+	}{
+		{
+			"not partial",
+			t.Context(),
+			sendChunks(
+				succeededChunk(newSpec(host1, oneMetric)),
+				failedChunk(newSpec(host2, oneMetric)),
+			),
+		},
+		{
+			"all failed",
+			partial,
+			sendChunks(
+				failedChunk(newSpec(host1, oneMetric)),
+				failedChunk(newSpec(host2, oneMetric)),
+			),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := mergeChunked(test.ctx, test.ch); !errors.Is(err, errChunk) {
+				t.Errorf("mergeChunked = %v", err)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25/mo"
@@ -22,6 +23,7 @@ type CounterInfo struct {
 	Rollup      string
 	Stats       string
 	Unit        string
+	Level       int32
 }
 
 func GetCounterInfo(ctx context.Context) (*[]CounterInfo, error) {
@@ -30,7 +32,9 @@ func GetCounterInfo(ctx context.Context) (*[]CounterInfo, error) {
 		return nil, err
 	}
 
-	defer sx.Logout(ctx, c)
+	defer logout(ctx, c)
+
+	defer ObservePhase(ctx, PhaseCounter, time.Now())
 
 	pc := property.DefaultCollector(c)
 
@@ -62,6 +66,7 @@ func ToCounterInfo(c *types.PerfCounterInfo) *CounterInfo {
 		Rollup:      fmt.Sprintf("%v", c.RollupType),
 		Stats:       fmt.Sprintf("%v", c.StatsType),
 		Unit:        c.UnitInfo.GetElementDescription().Key,
+		Level:       c.Level,
 	}
 }
 
@@ -0,0 +1,105 @@
+//revive:disable:max-public-structs
+
+package vmomi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
+)
+
+type CredentialProviderKey struct{}
+
+type Credential struct {
+	User     string
+	Password string
+}
+
+type CredentialProvider interface {
+	Credential(ctx context.Context) (*Credential, error)
+}
+
+type StaticCredentialProvider struct {
+	User     string
+	Password string
+}
+
+type FileCredentialProvider struct {
+	User         string
+	PasswordFile string
+}
+
+type ExecCredentialProvider struct {
+	User    string
+	Command []string
+}
+
+func (p *StaticCredentialProvider) Credential(_ context.Context) (*Credential, error) {
+	return &Credential{
+		User:     p.User,
+		Password: p.Password,
+	}, nil
+}
+
+func (p *FileCredentialProvider) Credential(_ context.Context) (*Credential, error) {
+	// Read every time to follow password rotation.
+	data, err := os.ReadFile(p.PasswordFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		User:     p.User,
+		Password: firstLine(data),
+	}, nil
+}
+
+func (p *ExecCredentialProvider) Credential(ctx context.Context) (*Credential, error) {
+	if len(p.Command) == empty {
+		return nil, errors.New("password command is empty")
+	}
+
+	//revive:disable:add-constant
+	cmd := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...)
+	//revive:enable:add-constant
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		User:     p.User,
+		Password: firstLine(out),
+	}, nil
+}
+
+func toCredentialFunc(provider CredentialProvider) sx.CredentialFunc {
+	return func(ctx context.Context) (*url.Userinfo, error) {
+		cred, err := provider.Credential(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return url.UserPassword(cred.User, cred.Password), nil
+	}
+}
+
+func firstLine(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() {
+		return ""
+	}
+
+	return strings.TrimRight(scanner.Text(), "\r")
+}
+
+//revive:enable:max-public-structs
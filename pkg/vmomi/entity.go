@@ -2,13 +2,13 @@ package vmomi
 
 import (
 	"context"
+	"time"
 
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 
 	px "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/propertyex"
-	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
 )
 
 type ManagedEntityType string
@@ -79,7 +79,7 @@ func GetEntity(
 		return nil, err
 	}
 
-	defer sx.Logout(ctx, c)
+	defer logout(ctx, c)
 
 	roots := []types.ManagedObjectReference{}
 	for _, e := range rootEntities {
@@ -110,7 +110,7 @@ func GetEntityFromRoot(ctx context.Context, entityTypes []ManagedEntityType) (*[
 		return nil, err
 	}
 
-	defer sx.Logout(ctx, c)
+	defer logout(ctx, c)
 
 	moTypes := []string{}
 	for _, t := range entityTypes {
@@ -134,6 +134,8 @@ func getEntities(
 	moTypes []string,
 	withRoot bool,
 ) (*[]mo.ManagedEntity, error) {
+	defer ObservePhase(ctx, PhaseInventory, time.Now())
+
 	objects, err := px.Retrieve(ctx, c, roots, moTypes, []string{"name"}, withRoot)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,73 @@
+package vmomi_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+func entityLines(entities []vmomi.Entity) []byte {
+	lines := []string{}
+	for _, e := range entities {
+		lines = append(lines, fmt.Sprintf("%v %v %v\n", e.Type, e.ID, e.Name))
+	}
+
+	return joinLines(lines)
+}
+
+func joinLines(lines []string) []byte {
+	slices.Sort(lines)
+	return []byte(strings.Join(lines, ""))
+}
+
+func findEntity(t *testing.T, entities []vmomi.Entity, name string) vmomi.Entity {
+	t.Helper()
+
+	for _, e := range entities {
+		if e.Name == name {
+			return e
+		}
+	}
+
+	t.Fatalf("Not found %v", name)
+	return vmomi.Entity{}
+}
+
+func TestGetEntityFromRoot(t *testing.T) {
+	sim := vcsimtest.Start(t)
+
+	entities, err := vmomi.GetEntityFromRoot(sim.Context, vmomi.ManagedEntityTypeValues())
+	if err != nil {
+		t.Fatalf("GetEntityFromRoot: %v", err)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/entity_root.golden", entityLines(*entities))
+}
+
+func TestGetEntity(t *testing.T) {
+	sim := vcsimtest.Start(t)
+
+	clusterType := []vmomi.ManagedEntityType{vmomi.ManagedEntityTypeClusterComputeResource}
+	clusters, err := vmomi.GetEntityFromRoot(sim.Context, clusterType)
+	if err != nil {
+		t.Fatalf("GetEntityFromRoot: %v", err)
+	}
+
+	roots := []vmomi.Entity{findEntity(t, *clusters, "DC0_C0")}
+	entityTypes := []vmomi.ManagedEntityType{
+		vmomi.ManagedEntityTypeClusterComputeResource,
+		vmomi.ManagedEntityTypeHostSystem,
+		vmomi.ManagedEntityTypeVirtualMachine,
+	}
+
+	entities, err := vmomi.GetEntity(sim.Context, roots, entityTypes, true)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/entity_cluster.golden", entityLines(*entities))
+}
@@ -0,0 +1,94 @@
+package vmomi
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	px "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/propertyex"
+)
+
+// excludeEntities removes excluded entities and descendants of excluded containers,
+// and returns the number of removed entities.
+func excludeEntities(
+	ctx context.Context,
+	c *vim25.Client,
+	entities *[]mo.ManagedEntity,
+	moTypes []string,
+	excludes []RootSpec,
+) (*[]mo.ManagedEntity, int, error) {
+	if len(excludes) == empty {
+		return entities, empty, nil
+	}
+
+	excluded, err := getExcludedRefs(ctx, c, moTypes, excludes)
+	if err != nil {
+		return nil, empty, err
+	}
+
+	kept := []mo.ManagedEntity{}
+	for _, e := range *entities {
+		if _, ok := excluded[e.Reference()]; !ok {
+			kept = append(kept, e)
+		}
+	}
+
+	return &kept, len(*entities) - len(kept), nil
+}
+
+func getExcludedRefs(
+	ctx context.Context,
+	c *vim25.Client,
+	moTypes []string,
+	excludes []RootSpec,
+) (map[types.ManagedObjectReference]struct{}, error) {
+	roots, err := resolveExcludes(ctx, c, excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := map[types.ManagedObjectReference]struct{}{}
+	if len(roots) == empty {
+		return excluded, nil
+	}
+
+	// Datastore and network relations reach entities outside of the excluded containers.
+	cctx := context.WithValue(ctx, px.ContainmentOnlyKey{}, true)
+	descendants, err := getEntities(cctx, c, roots, moTypes, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range *descendants {
+		excluded[e.Reference()] = struct{}{}
+	}
+
+	return excluded, nil
+}
+
+func resolveExcludes(
+	ctx context.Context,
+	c *vim25.Client,
+	excludes []RootSpec,
+) ([]types.ManagedObjectReference, error) {
+	r := rootResolver{
+		client:   c,
+		entities: map[ManagedEntityType][]Entity{},
+	}
+
+	roots := []types.ManagedObjectReference{}
+	for _, s := range excludes {
+		entities, err := r.find(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entities {
+			roots = append(roots, types.ManagedObjectReference{Type: string(e.Type), Value: e.ID})
+		}
+	}
+
+	return roots, nil
+}
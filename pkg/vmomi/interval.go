@@ -0,0 +1,144 @@
+package vmomi
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	IntervalAuto       = ""
+	IntervalRealtime   = "realtime"
+	IntervalHistorical = "historical"
+)
+
+const (
+	// Use 30min because datastore min period is 30min.
+	defaultLookback = 30 * time.Minute
+	// Realtime statistics are kept for 1 hour.
+	realtimeRetention = time.Hour
+	intervalBase      = 10
+	intervalBitSize   = 32
+)
+
+type QueryObject struct {
+	Type     ManagedEntityType
+	Interval string
+	Lookback time.Duration
+}
+
+func ValidateInterval(interval string) error {
+	switch interval {
+	case IntervalAuto, IntervalRealtime, IntervalHistorical:
+		return nil
+	default:
+		_, err := parseIntervalSeconds(interval)
+		return err
+	}
+}
+
+func ToMoTypes(objects []QueryObject) []string {
+	moTypes := []string{}
+	for _, o := range objects {
+		moTypes = append(moTypes, string(o.Type))
+	}
+
+	return moTypes
+}
+
+func findQueryObject(objects []QueryObject, moType string) QueryObject {
+	for _, o := range objects {
+		if string(o.Type) == moType {
+			return o
+		}
+	}
+
+	return QueryObject{
+		Type:     ManagedEntityType(moType),
+		Interval: IntervalAuto,
+		Lookback: time.Duration(empty),
+	}
+}
+
+// selectIntervalID returns nil without error if entity does not support statistics.
+func selectIntervalID(intervals []IntervalID, interval string) (*IntervalID, error) {
+	match, err := intervalMatcher(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	intervalID := smallestIntervalID(intervals, match)
+	if intervalID == nil && len(intervals) != empty {
+		return nil, fmt.Errorf("not supported interval %v", interval)
+	}
+
+	return intervalID, nil
+}
+
+func intervalMatcher(interval string) (func(IntervalID) bool, error) {
+	switch interval {
+	case IntervalAuto:
+		return func(IntervalID) bool { return true }, nil
+	case IntervalRealtime:
+		return func(i IntervalID) bool { return i.Current }, nil
+	case IntervalHistorical:
+		return func(i IntervalID) bool { return !i.Current }, nil
+	default:
+		seconds, err := parseIntervalSeconds(interval)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(i IntervalID) bool { return i.ID == seconds }, nil
+	}
+}
+
+func smallestIntervalID(intervals []IntervalID, match func(IntervalID) bool) *IntervalID {
+	var intervalID *IntervalID
+	for _, interval := range intervals {
+		if !match(interval) {
+			continue
+		}
+
+		if intervalID == nil || interval.ID < intervalID.ID {
+			intervalID = &interval
+		}
+	}
+
+	return intervalID
+}
+
+// coveringIntervalID returns the smallest interval keeping samples from age ago.
+func coveringIntervalID(intervals []IntervalID, age time.Duration) *IntervalID {
+	covers := func(i IntervalID) bool { return i.Retention >= age }
+	covering := smallestIntervalID(intervals, covers)
+	if covering != nil || len(intervals) == empty {
+		return covering
+	}
+
+	// Keep samples as many as possible.
+	longest := slices.MaxFunc(intervals, func(a, b IntervalID) int {
+		return cmp.Compare(a.Retention, b.Retention)
+	})
+	return &longest
+}
+
+func parseIntervalSeconds(interval string) (int32, error) {
+	seconds, err := strconv.ParseInt(interval, intervalBase, intervalBitSize)
+	if err != nil || seconds <= int64(empty) {
+		return empty32, fmt.Errorf("invalid interval %v", interval)
+	}
+
+	return int32(seconds), nil
+}
+
+func lookbackOf(object QueryObject, intervalID IntervalID) time.Duration {
+	if object.Lookback > time.Duration(empty) || intervalID.Current {
+		return object.Lookback
+	}
+
+	// Limit sampling using period because MaxSample is ignored for historical statistics.
+	return defaultLookback
+}
@@ -0,0 +1,85 @@
+package vmomi
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	realtimeID = int32(20)
+	dayID      = int32(300)
+	weekID     = int32(1800)
+	day        = 24 * time.Hour
+	week       = 7 * day
+	month      = 30 * day
+)
+
+func TestCoveringIntervalID(t *testing.T) {
+	intervals := newIntervals()
+
+	tests := []struct {
+		age      time.Duration
+		expected int32
+	}{
+		{time.Duration(empty), realtimeID},
+		{time.Hour, realtimeID},
+		{day, dayID},
+		{week, weekID},
+		{month, weekID},
+	}
+
+	for _, test := range tests {
+		if i := coveringIntervalID(intervals, test.age); i == nil || i.ID != test.expected {
+			t.Errorf("coveringIntervalID(%v) = %v", test.age, i)
+		}
+	}
+
+	if i := coveringIntervalID([]IntervalID{}, time.Hour); i != nil {
+		t.Errorf("coveringIntervalID(empty) = %v", i)
+	}
+}
+
+func newIntervals() []IntervalID {
+	return []IntervalID{
+		{ID: dayID, Retention: day},
+		{ID: realtimeID, Current: true, Retention: realtimeRetention},
+		{ID: weekID, Retention: week},
+	}
+}
+
+func TestSelectIntervalID(t *testing.T) {
+	tests := []struct {
+		interval string
+		expected int32
+	}{
+		{IntervalAuto, realtimeID},
+		{IntervalRealtime, realtimeID},
+		{IntervalHistorical, dayID},
+		{"1800", weekID},
+	}
+
+	for _, test := range tests {
+		i, err := selectIntervalID(newIntervals(), test.interval)
+		if err != nil || i == nil || i.ID != test.expected {
+			t.Errorf("selectIntervalID(%v) = %v, %v", test.interval, i, err)
+		}
+	}
+
+	// Entity which does not support statistics is skipped.
+	if i, err := selectIntervalID([]IntervalID{}, IntervalRealtime); i != nil || err != nil {
+		t.Errorf("selectIntervalID(empty) = %v, %v", i, err)
+	}
+}
+
+func TestSelectIntervalIDNotSupported(t *testing.T) {
+	for _, interval := range []string{"7200", "0", "none"} {
+		if i, err := selectIntervalID(newIntervals(), interval); err == nil {
+			t.Errorf("selectIntervalID(%v) = %v", interval, i)
+		}
+	}
+
+	historical := []IntervalID{{ID: dayID, Retention: day}}
+	if i, err := selectIntervalID(historical, IntervalRealtime); err == nil {
+		t.Errorf("selectIntervalID(historical) = %v", i)
+	}
+}
@@ -1,6 +1,7 @@
 package vmomi
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -15,7 +16,6 @@ import (
 	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
-	"golang.org/x/sync/semaphore"
 
 	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
 	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
@@ -44,15 +44,37 @@ type InstanceInfo struct {
 }
 
 type IntervalID struct {
-	ID      int32
-	Current bool
+	ID        int32
+	Current   bool
+	Retention time.Duration
+}
+
+type QueryResult struct {
+	Metrics         []Metric
+	Aggregates      []AggregateMetric
+	FailedChunks    int
+	FailedEntities  []Entity
+	SkippedEntities int
+}
+
+type queryEntities struct {
+	entities  *[]mo.ManagedEntity
+	hierarchy *hierarchy
+	skipped   int
 }
 
 type chunkedBasePerfEntityMetricBase struct {
+	Specs   *[]types.PerfQuerySpec
 	Metrics *[]types.BasePerfEntityMetricBase
 	Err     error
 }
 
+type chunkedResult struct {
+	Metrics      []types.BasePerfEntityMetricBase
+	FailedChunks int
+	FailedSpecs  []types.PerfQuerySpec
+}
+
 func GetInstanceInfo(
 	ctx context.Context,
 	entityTypes []ManagedEntityType,
@@ -62,7 +84,7 @@ func GetInstanceInfo(
 		return nil, err
 	}
 
-	defer sx.Logout(ctx, c)
+	defer logout(ctx, c)
 
 	serverClock, err := sx.ExecCallAPI(
 		ctx,
@@ -91,7 +113,15 @@ func GetInstanceInfo(
 	}
 
 	pm := performance.NewManager(c)
-	specs, err := createQuerySpecs(ctx, serverClock, pm, p.HistoricalInterval, entities, nil)
+	specs, err := createQuerySpecs(
+		ctx,
+		serverClock,
+		pm,
+		p.HistoricalInterval,
+		entities,
+		nil,
+		nil,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +136,7 @@ func GetIntervalInfo(ctx context.Context, entity Entity) ([]IntervalID, error) {
 		return nil, err
 	}
 
-	defer sx.Logout(ctx, c)
+	defer logout(ctx, c)
 
 	p, err := getPerformanceManager(ctx, c)
 	if err != nil {
@@ -159,15 +189,18 @@ func ToInstanceInfo(
 func Query(
 	ctx context.Context,
 	rootEntities *[]Entity,
-	moTypes []string,
+	objects []QueryObject,
 	counters []CounterInfo,
-) ([]Metric, error) {
+	opts ...func(o *QueryOptions),
+) (*QueryResult, error) {
+	opt := toQueryOptions(opts)
+
 	c, err := login(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	defer sx.Logout(ctx, c)
+	defer logout(ctx, c)
 
 	serverClock, err := sx.ExecCallAPI(
 		ctx,
@@ -186,91 +219,77 @@ func Query(
 
 	cnts := ComplementCounterInfoList(ctx, *p, counters)
 
-	roots := toRootManagedObjectReference(c, rootEntities)
-
-	entities, err := getEntities(ctx, c, roots, moTypes, rootEntities != nil)
-	if err != nil {
-		return nil, err
-	}
-
-	pm := performance.NewManager(c)
-	specs, err := createQuerySpecs(ctx, serverClock, pm, p.HistoricalInterval, entities, &cnts)
-	if err != nil {
-		return nil, err
-	}
-
-	entityMetrics, err := queryChunked(ctx, pm, specs)
-	if err != nil {
-		return nil, err
-	}
-
-	return ToMetrics(ctx, p, entities, &entityMetrics)
-}
-
-func QueryEntity(
-	ctx context.Context,
-	entity Entity,
-	interval int32,
-	counterID int32,
-) ([]Metric, error) {
-	c, err := login(ctx)
+	queried, err := getQueryEntities(ctx, c, rootEntities, objects, opt)
 	if err != nil {
 		return nil, err
 	}
 
-	defer sx.Logout(ctx, c)
+	entities := queried.entities
 
-	serverClock, err := sx.ExecCallAPI(
+	pm := performance.NewManager(c)
+	specs, err := createQuerySpecs(
 		ctx,
-		func(cctx context.Context) (*time.Time, error) {
-			return methods.GetCurrentTime(cctx, c)
-		},
+		serverClock,
+		pm,
+		p.HistoricalInterval,
+		entities,
+		&cnts,
+		objects,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	p, err := getPerformanceManager(ctx, c)
+	chunked, err := queryChunked(ctx, pm, specs)
 	if err != nil {
 		return nil, err
 	}
 
-	roots := []types.ManagedObjectReference{c.ServiceContent.RootFolder}
-	entities, err := getEntities(ctx, c, roots, []string{string(entity.Type)}, false)
+	metrics, err := ToMetrics(ctx, p, entities, &chunked.Metrics)
 	if err != nil {
 		return nil, err
 	}
 
-	found, err := findRootEntity(entities, entity)
-	if err != nil {
-		return nil, err
+	result := QueryResult{
+		Metrics:         metrics,
+		Aggregates:      queryAggregates(ctx, metrics, queried.hierarchy, opt.Aggregates),
+		FailedChunks:    chunked.FailedChunks,
+		FailedEntities:  toEntitiesFromSpecs(entities, chunked.FailedSpecs),
+		SkippedEntities: queried.skipped,
 	}
 
-	pm := performance.NewManager(c)
+	return &result, nil
+}
 
-	specs, err := createQuerySpecEntity(
-		ctx,
-		serverClock,
-		pm,
-		found,
-		interval,
-		counterID,
-	)
+func getQueryEntities(
+	ctx context.Context,
+	c *vim25.Client,
+	rootEntities *[]Entity,
+	objects []QueryObject,
+	opt QueryOptions,
+) (*queryEntities, error) {
+	roots := toRootManagedObjectReference(c, rootEntities)
+	moTypes := ToMoTypes(objects)
+	withRoot := rootEntities != nil
+
+	var entities *[]mo.ManagedEntity
+	var h *hierarchy
+	var err error
+	if len(opt.Aggregates) != empty {
+		entities, h, err = getEntitiesWithHierarchy(ctx, c, roots, moTypes, withRoot)
+	} else {
+		entities, err = getEntities(ctx, c, roots, moTypes, withRoot)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	entityMetrics, err := sx.ExecCallAPI(
-		ctx,
-		func(cctx context.Context) ([]types.BasePerfEntityMetricBase, error) {
-			return pm.Query(cctx, *specs)
-		},
-	)
+	kept, skipped, err := excludeEntities(ctx, c, entities, moTypes, opt.Excludes)
 	if err != nil {
 		return nil, err
 	}
 
-	return ToMetrics(ctx, p, entities, &entityMetrics)
+	return &queryEntities{entities: kept, hierarchy: h, skipped: skipped}, nil
 }
 
 func ToMetrics(
@@ -279,6 +298,8 @@ func ToMetrics(
 	entities *[]mo.ManagedEntity,
 	entityMetrics *[]types.BasePerfEntityMetricBase,
 ) ([]Metric, error) {
+	defer ObservePhase(ctx, PhaseConversion, time.Now())
+
 	metrics := []Metric{}
 	for _, s := range *entityMetrics {
 		m, err := ToMetric(p, entities, s)
@@ -330,11 +351,15 @@ func createQuerySpecs(
 	intervalIDs []types.PerfInterval,
 	entities *[]mo.ManagedEntity,
 	counters *[]CounterInfo,
+	objects []QueryObject,
 ) (*[]types.PerfQuerySpec, error) {
+	defer ObservePhase(ctx, PhaseAvailableMetric, time.Now())
+
 	querySpecs := []types.PerfQuerySpec{}
 	intervalIDCache := map[string]IntervalID{}
 	for _, entity := range *entities {
-		intervalID := findIntervalID(ctx, pm, intervalIDs, intervalIDCache, entity)
+		object := findQueryObject(objects, entity.Reference().Type)
+		intervalID := findIntervalID(ctx, pm, intervalIDs, intervalIDCache, entity, object)
 		if intervalID == nil {
 			// Not support current and historical.
 			continue
@@ -346,6 +371,7 @@ func createQuerySpecs(
 			pm,
 			&entity,
 			*intervalID,
+			lookbackOf(object, *intervalID),
 			counters,
 		)
 		if err != nil {
@@ -363,43 +389,13 @@ func createQuerySpecs(
 	return &querySpecs, nil
 }
 
-func createQuerySpecEntity(
-	ctx context.Context,
-	serverClock *time.Time,
-	pm *performance.Manager,
-	entity *mo.ManagedEntity,
-	interval int32,
-	counterID int32,
-) (*[]types.PerfQuerySpec, error) {
-	intervalID := IntervalID{
-		ID:      interval,
-		Current: true,
-	}
-
-	specs, err := createQuerySpec(
-		ctx,
-		serverClock,
-		pm,
-		entity,
-		intervalID,
-		&[]CounterInfo{{ID: counterID}},
-	)
-	if err != nil {
-		return nil, err
-	} else if specs == nil {
-		return nil, errors.New("not found counter")
-	}
-
-	querySpecs := []types.PerfQuerySpec{*specs}
-	return &querySpecs, nil
-}
-
 func createQuerySpec(
 	ctx context.Context,
 	serverClock *time.Time,
 	pm *performance.Manager,
 	e *mo.ManagedEntity,
 	intervalID IntervalID,
+	lookback time.Duration,
 	counters *[]CounterInfo,
 ) (*types.PerfQuerySpec, error) {
 	metrics, err := sx.ExecCallAPI(
@@ -419,10 +415,8 @@ func createQuerySpec(
 	}
 
 	var startTime *time.Time
-	if !intervalID.Current {
-		// Limit sampling using period.
-		// Use 30min because datastore min period is 30min.
-		t := serverClock.Add(-30 * time.Minute)
+	if lookback > time.Duration(empty) {
+		t := serverClock.Add(-lookback)
 		startTime = &t
 	}
 
@@ -442,20 +436,14 @@ func getIntervalID(
 	pm *performance.Manager,
 	intervalIDs []types.PerfInterval,
 	e types.ManagedObjectReference,
+	interval string,
 ) (*IntervalID, error) {
 	intervals, err := getIntervalIDs(ctx, pm, intervalIDs, e)
 	if err != nil {
 		return nil, err
 	}
 
-	var intervalID *IntervalID
-	for _, interval := range intervals {
-		if intervalID == nil || interval.ID < intervalID.ID {
-			intervalID = &interval
-		}
-	}
-
-	return intervalID, nil
+	return selectIntervalID(intervals, interval)
 }
 
 func getIntervalIDs(
@@ -478,8 +466,9 @@ func getIntervalIDs(
 
 	if summary.CurrentSupported {
 		i := IntervalID{
-			ID:      summary.RefreshRate,
-			Current: true,
+			ID:        summary.RefreshRate,
+			Current:   true,
+			Retention: realtimeRetention,
 		}
 		intervals = append(intervals, i)
 	}
@@ -487,8 +476,9 @@ func getIntervalIDs(
 	if summary.SummarySupported {
 		for _, interval := range intervalIDs {
 			i := IntervalID{
-				ID:      interval.SamplingPeriod,
-				Current: false,
+				ID:        interval.SamplingPeriod,
+				Current:   false,
+				Retention: time.Duration(interval.Length) * time.Second,
 			}
 			intervals = append(intervals, i)
 		}
@@ -498,6 +488,8 @@ func getIntervalIDs(
 }
 
 func getPerformanceManager(ctx context.Context, c *vim25.Client) (*mo.PerformanceManager, error) {
+	defer ObservePhase(ctx, PhaseCounter, time.Now())
+
 	pc := property.DefaultCollector(c)
 
 	var p mo.PerformanceManager
@@ -530,13 +522,20 @@ func findIntervalID(
 	intervalIDs []types.PerfInterval,
 	intervalIDCache map[string]IntervalID,
 	entity mo.ManagedEntity,
+	object QueryObject,
 ) *IntervalID {
 	moType := entity.Reference().Type
 
 	if _, ok := intervalIDCache[moType]; !ok {
-		intervalID, err := getIntervalID(ctx, pm, intervalIDs, entity.Reference())
+		intervalID, err := getIntervalID(ctx, pm, intervalIDs, entity.Reference(), object.Interval)
 		if err != nil {
-			slog.WarnContext(ctx, "Could not get interval", "error", err, "type", moType)
+			slog.WarnContext(
+				ctx,
+				"Could not get interval",
+				"error", err,
+				"type", moType,
+				"interval", object.Interval,
+			)
 		}
 
 		if intervalID == nil {
@@ -550,7 +549,7 @@ func findIntervalID(
 	}
 
 	if intervalIDCache[moType].ID == empty32 {
-		// Not support current and historical.
+		// Not support statistics or configured interval.
 		return nil
 	}
 
@@ -558,20 +557,25 @@ func findIntervalID(
 	return &id
 }
 
-func findRootEntity(entities *[]mo.ManagedEntity, root Entity) (*mo.ManagedEntity, error) {
-	var found *mo.ManagedEntity
-	for _, e := range *entities {
-		if e.Reference().Value == root.ID {
-			found = &e
-			break
+func toEntitiesFromSpecs(
+	entities *[]mo.ManagedEntity,
+	specs []types.PerfQuerySpec,
+) []Entity {
+	failed := []Entity{}
+	for _, spec := range specs {
+		e := Entity{
+			ID:   spec.Entity.Value,
+			Name: findEntityName(entities, spec.Entity),
+			Type: ManagedEntityType(spec.Entity.Type),
 		}
-	}
 
-	if found == nil {
-		return nil, fmt.Errorf("entity not found %v(%v)", root.Type, root.ID)
+		// Metric IDs of an entity may be split into some chunks.
+		if !slices.Contains(failed, e) {
+			failed = append(failed, e)
+		}
 	}
 
-	return found, nil
+	return failed
 }
 
 func toRootManagedObjectReference(
@@ -677,6 +681,16 @@ func getEntityChunkSize(ctx context.Context) int {
 	return chunkSize
 }
 
+func getPartialResults(ctx context.Context) bool {
+	partial, ok := ctx.Value(flag.TargetPartialResultsKey{}).(bool)
+	return ok && partial
+}
+
+func tolerateChunkError(ctx context.Context) bool {
+	// Export chunks finished by the deadline.
+	return getPartialResults(ctx) || errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
 func getMaxConcurrency(ctx context.Context) int64 {
 	concurrency, ok := ctx.Value(flag.TargetMaxConcurrency{}).(int)
 	//revive:disable:add-constant
@@ -692,29 +706,30 @@ func queryChunked(
 	ctx context.Context,
 	pm *performance.Manager,
 	specs *[]types.PerfQuerySpec,
-) ([]types.BasePerfEntityMetricBase, error) {
-	chukSize := getEntityChunkSize(ctx)
-	//revive:disable:add-constant
-	chunkCount := (len(*specs) / chukSize) + 1
-	//revive:enable:add-constant
+) (*chunkedResult, error) {
+	defer ObservePhase(ctx, PhaseQueryPerf, time.Now())
 
-	concurrencySize := getMaxConcurrency(ctx)
-	concurrency := semaphore.NewWeighted(concurrencySize)
+	entityChunkSize := getEntityChunkSize(ctx)
+	metricChunkSize := getMetricChunkSize(ctx)
+	chunks := packSpecs(*specs, entityChunkSize, metricChunkSize)
+
+	limiter := newConcurrencyLimiter(ctx)
 
 	debugStartedLog(
 		ctx,
 		"entityCount", len(*specs),
-		"chukSize", chukSize,
-		"chunkCount", chunkCount,
-		"concurrencySize", concurrencySize,
+		"entityChunkSize", entityChunkSize,
+		"metricChunkSize", metricChunkSize,
+		"chunkCount", len(chunks),
+		"concurrencySize", limiter.limit,
 	)
 
-	ch := make(chan chunkedBasePerfEntityMetricBase, chunkCount)
+	ch := make(chan chunkedBasePerfEntityMetricBase, len(chunks))
 
 	var wg sync.WaitGroup
-	for chunkedSpecs := range slices.Chunk(*specs, chukSize) {
+	for _, chunkedSpecs := range chunks {
 		wg.Go(func() {
-			queryOnce(ctx, pm, &chunkedSpecs, ch, concurrency)
+			queryOnce(ctx, pm, &chunkedSpecs, ch, limiter)
 		})
 	}
 	wg.Wait()
@@ -723,17 +738,51 @@ func queryChunked(
 
 	debugCompletedLog(ctx)
 
-	entityMetrics := []types.BasePerfEntityMetricBase{}
+	result, err := mergeChunked(ctx, ch)
+	if err == nil && result.FailedChunks == empty {
+		learnQuerySucceeded(ctx)
+	}
+
+	return result, err
+}
+
+func mergeChunked(
+	ctx context.Context,
+	ch <-chan chunkedBasePerfEntityMetricBase,
+) (*chunkedResult, error) {
+	partial := tolerateChunkError(ctx)
+
+	result := chunkedResult{
+		Metrics:      []types.BasePerfEntityMetricBase{},
+		FailedChunks: empty,
+		FailedSpecs:  []types.PerfQuerySpec{},
+	}
+
+	var firstErr error
+	succeeded := empty
 	for chunkedMetrics := range ch {
-		if chunkedMetrics.Err != nil {
+		if chunkedMetrics.Err == nil {
+			succeeded++
+			result.Metrics = append(result.Metrics, *chunkedMetrics.Metrics...)
+			debugStartedLog(ctx, "merge", len(result.Metrics))
+			continue
+		}
+
+		if !partial {
 			return nil, chunkedMetrics.Err
 		}
 
-		entityMetrics = append(entityMetrics, *chunkedMetrics.Metrics...)
-		debugStartedLog(ctx, "merge", len(entityMetrics))
+		firstErr = cmp.Or(firstErr, chunkedMetrics.Err)
+		result.FailedChunks++
+		result.FailedSpecs = append(result.FailedSpecs, *chunkedMetrics.Specs...)
+	}
+
+	if succeeded == empty && firstErr != nil {
+		// Not partial because all chunks are failed.
+		return nil, firstErr
 	}
 
-	return entityMetrics, nil
+	return &result, nil
 }
 
 func queryOnce(
@@ -741,31 +790,14 @@ func queryOnce(
 	pm *performance.Manager,
 	specs *[]types.PerfQuerySpec,
 	ch chan<- chunkedBasePerfEntityMetricBase,
-	concurrency *semaphore.Weighted,
+	limiter *concurrencyLimiter,
 ) {
-	//revive:disable:add-constant
-	err := concurrency.Acquire(ctx, 1)
-	if err != nil {
-		ch <- chunkedBasePerfEntityMetricBase{
-			Metrics: nil,
-			Err:     err,
-		}
-		return
-	}
-
-	defer concurrency.Release(1)
-	//revive:enable:add-constant
-
 	debugStartedLog(ctx)
 
-	entityMetrics, err := sx.ExecCallAPI(
-		ctx,
-		func(cctx context.Context) ([]types.BasePerfEntityMetricBase, error) {
-			return pm.Query(cctx, *specs)
-		},
-	)
+	entityMetrics, err := querySpecs(ctx, pm, limiter, *specs)
 
 	ch <- chunkedBasePerfEntityMetricBase{
+		Specs:   specs,
 		Metrics: &entityMetrics,
 		Err:     err,
 	}
@@ -0,0 +1,222 @@
+package vmomi_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	empty       = 0
+	cpuUsage    = "cpu.usage.average"
+	queryFailed = "Query: %v"
+	// Exclude a VM of DC0_H0 by name and another by MoRef ID.
+	excludeMachines = 3
+	excludedByName  = "DC0_H0_VM0"
+	excludedByID    = "DC0_H0_VM1"
+	// Limit samples because vcsim generates them for a year without start time.
+	lookback = 1 * time.Minute
+)
+
+var queryObjects = []vmomi.QueryObject{
+	{Type: vmomi.ManagedEntityTypeHostSystem, Lookback: lookback},
+	{Type: vmomi.ManagedEntityTypeVirtualMachine, Lookback: lookback},
+}
+
+func findCounters(t *testing.T, sim *vcsimtest.Simulator, names ...string) []vmomi.CounterInfo {
+	t.Helper()
+
+	info, err := vmomi.GetCounterInfo(sim.Context)
+	if err != nil {
+		t.Fatalf("GetCounterInfo: %v", err)
+	}
+
+	counters := []vmomi.CounterInfo{}
+	for _, c := range *info {
+		if slices.Contains(names, fmt.Sprintf("%v.%v.%v", c.Group, c.Name, c.Rollup)) {
+			counters = append(counters, c)
+		}
+	}
+
+	if len(counters) != len(names) {
+		t.Fatalf("Not found counters %v", names)
+	}
+
+	return counters
+}
+
+func queryRoots(t *testing.T, sim *vcsimtest.Simulator) *[]vmomi.Entity {
+	t.Helper()
+
+	roots, err := vmomi.GetEntityFromRoot(
+		sim.Context,
+		[]vmomi.ManagedEntityType{vmomi.ManagedEntityTypeDatacenter},
+	)
+	if err != nil {
+		t.Fatalf("GetEntityFromRoot: %v", err)
+	}
+
+	return roots
+}
+
+func TestGetInstanceInfo(t *testing.T) {
+	sim := vcsimtest.Start(t)
+
+	entityTypes := []vmomi.ManagedEntityType{vmomi.ManagedEntityTypeVirtualMachine}
+	info, err := vmomi.GetInstanceInfo(sim.Context, entityTypes)
+	if err != nil {
+		t.Fatalf("GetInstanceInfo: %v", err)
+	}
+
+	lines := []string{}
+	for _, i := range *info {
+		line := fmt.Sprintf(
+			"%v %v %v %v %q\n",
+			i.EntityType,
+			i.EntityID,
+			i.EntityName,
+			i.CounterID,
+			i.Instance,
+		)
+		lines = append(lines, line)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/instance_vm.golden", joinLines(lines))
+}
+
+func TestQuery(t *testing.T) {
+	sim := vcsimtest.Start(t)
+	counters := findCounters(t, sim, cpuUsage, "mem.usage.average")
+
+	started := time.Now()
+	result, err := vmomi.Query(sim.Context, queryRoots(t, sim), queryObjects, counters)
+	if err != nil {
+		t.Fatalf(queryFailed, err)
+	}
+
+	lines := []string{}
+	for _, m := range result.Metrics {
+		if m.Timestamp.Before(started.Add(-lookback)) || m.Value < empty {
+			t.Errorf("Invalid sample %v", m)
+		}
+
+		line := fmt.Sprintf(
+			"%v %v %v %v.%v.%v %q %v\n",
+			m.Entity.Type,
+			m.Entity.ID,
+			m.Entity.Name,
+			m.Counter.Group,
+			m.Counter.Name,
+			m.Counter.Rollup,
+			m.Instance,
+			m.Interval,
+		)
+		lines = append(lines, line)
+	}
+
+	if result.FailedChunks != empty {
+		t.Errorf("Failed chunks %v", result.FailedChunks)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/query.golden", joinLines(lines))
+}
+
+func TestQueryAggregates(t *testing.T) {
+	sim := vcsimtest.Start(t)
+	counters := findCounters(t, sim, cpuUsage)
+
+	aggregates := []vmomi.AggregateSpec{
+		{
+			Type:      vmomi.ManagedEntityTypeClusterComputeResource,
+			Functions: []string{vmomi.AggregateSum, vmomi.AggregateMax},
+		},
+		{
+			Type:      vmomi.AggregateTypeVCenter,
+			Functions: []string{vmomi.AggregateSum},
+		},
+	}
+
+	result, err := vmomi.Query(
+		sim.Context,
+		queryRoots(t, sim),
+		queryObjects,
+		counters,
+		vmomi.WithAggregates(aggregates),
+	)
+	if err != nil {
+		t.Fatalf(queryFailed, err)
+	}
+
+	lines := []string{}
+	for _, a := range result.Aggregates {
+		line := fmt.Sprintf(
+			"%v %v %v %v\n",
+			a.Entity.Type,
+			a.Entity.Name,
+			a.SourceType,
+			a.Function,
+		)
+		lines = append(lines, line)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/query_aggregates.golden", joinLines(lines))
+}
+
+func TestQueryExcludes(t *testing.T) {
+	sim := vcsimtest.Start(t, vcsimtest.WithMachine(excludeMachines))
+	counters := findCounters(t, sim, cpuUsage)
+
+	all, err := vmomi.GetEntityFromRoot(
+		sim.Context,
+		[]vmomi.ManagedEntityType{
+			vmomi.ManagedEntityTypeHostSystem,
+			vmomi.ManagedEntityTypeVirtualMachine,
+		},
+	)
+	if err != nil {
+		t.Fatalf("GetEntityFromRoot: %v", err)
+	}
+
+	vm := vmomi.ManagedEntityTypeVirtualMachine
+	// VMs of DC0_H0 share datastore and network with the cluster, but are not excluded.
+	excludes := []vmomi.RootSpec{
+		{Path: "/DC0/host/DC0_C0"},
+		{Type: vm, Name: excludedByName},
+		{Type: vm, ID: findEntity(t, *all, excludedByID).ID},
+	}
+
+	result, err := vmomi.Query(
+		sim.Context,
+		queryRoots(t, sim),
+		queryObjects,
+		counters,
+		vmomi.WithExcludes(excludes),
+	)
+	if err != nil {
+		t.Fatalf(queryFailed, err)
+	}
+
+	excluded := slices.DeleteFunc(slices.Clone(*all), func(e vmomi.Entity) bool {
+		return !strings.HasPrefix(e.Name, "DC0_C0_") &&
+			e.Name != excludedByName &&
+			e.Name != excludedByID
+	})
+	if result.SkippedEntities != len(excluded) {
+		t.Errorf("Skipped %v, expected %v", result.SkippedEntities, excluded)
+	}
+
+	lines := []string{fmt.Sprintf("skipped %v\n", result.SkippedEntities)}
+	for _, m := range result.Metrics {
+		line := fmt.Sprintf("%v %v %v\n", m.Entity.Type, m.Entity.ID, m.Entity.Name)
+		if !slices.Contains(lines, line) {
+			lines = append(lines, line)
+		}
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/query_excludes.golden", joinLines(lines))
+}
@@ -0,0 +1,61 @@
+package vmomi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	PhaseLogin           = "login"
+	PhaseCounter         = "counter"
+	PhaseInventory       = "inventory"
+	PhaseAvailableMetric = "available_metric"
+	PhaseQueryPerf       = "query_perf"
+	PhaseConversion      = "conversion"
+)
+
+type PhaseTimingsKey struct{}
+
+type PhaseTimings struct {
+	durations map[string]time.Duration
+	rock      sync.Mutex
+}
+
+func NewPhaseTimings() *PhaseTimings {
+	return &PhaseTimings{
+		durations: map[string]time.Duration{},
+	}
+}
+
+func Phases() []string {
+	return []string{
+		PhaseLogin,
+		PhaseCounter,
+		PhaseInventory,
+		PhaseAvailableMetric,
+		PhaseQueryPerf,
+		PhaseConversion,
+	}
+}
+
+func (t *PhaseTimings) Add(phase string, d time.Duration) {
+	t.rock.Lock()
+	defer t.rock.Unlock()
+
+	t.durations[phase] += d
+}
+
+func (t *PhaseTimings) Get(phase string) time.Duration {
+	t.rock.Lock()
+	defer t.rock.Unlock()
+
+	return t.durations[phase]
+}
+
+// ObservePhase adds the elapsed time from started if timings are in context.
+func ObservePhase(ctx context.Context, phase string, started time.Time) {
+	if t, ok := ctx.Value(PhaseTimingsKey{}).(*PhaseTimings); ok {
+		t.Add(phase, time.Since(started))
+	}
+}
@@ -2,6 +2,8 @@ package propertyex
 
 import (
 	"context"
+	"maps"
+	"slices"
 
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25"
@@ -17,6 +19,21 @@ func Retrieve(
 	moTypes []string,
 	pathSet []string,
 	withRoot bool,
+) ([]types.ObjectContent, error) {
+	pathSets := map[string][]string{}
+	for _, moType := range moTypes {
+		pathSets[moType] = pathSet
+	}
+
+	return RetrieveWithPathSet(ctx, c, roots, pathSets, withRoot)
+}
+
+func RetrieveWithPathSet(
+	ctx context.Context,
+	c *vim25.Client,
+	roots []types.ManagedObjectReference,
+	pathSets map[string][]string,
+	withRoot bool,
 ) ([]types.ObjectContent, error) {
 	pc := property.DefaultCollector(c)
 
@@ -26,10 +43,10 @@ func Retrieve(
 	}
 
 	props := []types.PropertySpec{}
-	for _, moType := range moTypes {
+	for _, moType := range slices.Sorted(maps.Keys(pathSets)) {
 		spec := types.PropertySpec{
 			Type:    moType,
-			PathSet: pathSet,
+			PathSet: pathSets[moType],
 		}
 
 		props = append(props, spec)
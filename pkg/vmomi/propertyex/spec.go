@@ -9,6 +9,10 @@ import (
 type IgnoreDatastoreVMKey struct{}
 type IgnoreNetworkVMKey struct{}
 
+// ContainmentOnlyKey skips datastore and network relations
+// to traverse only entities contained in the root.
+type ContainmentOnlyKey struct{}
+
 const (
 	ComputeResourceName             = "ComputeResource"
 	DatacenterName                  = "Datacenter"
@@ -61,18 +65,25 @@ func createComputeResourceLower(
 	ctx context.Context,
 	cache map[string]*types.TraversalSpec,
 ) []types.BaseSelectionSpec {
-	datastore := initSpec(ctx, ComputeResourceName, DatastoreProperty, cache)
-	setSelectSet(ctx, datastore, createDatastoreLower, cache)
-
 	host := initSpec(ctx, ComputeResourceName, HostProperty, cache)
 	setSelectSet(ctx, host, createHostSystemLower, cache)
 
-	network := initSpec(ctx, ComputeResourceName, NetworkProperty, cache)
-	setSelectSet(ctx, network, createNetworkLower, cache)
-
 	resourcePool := initSpec(ctx, ComputeResourceName, ResourcePoolProperty, cache)
 	setSelectSet(ctx, resourcePool, createResourcePoolLower, cache)
 
+	if isContainmentOnly(ctx) {
+		return []types.BaseSelectionSpec{
+			host,
+			resourcePool,
+		}
+	}
+
+	datastore := initSpec(ctx, ComputeResourceName, DatastoreProperty, cache)
+	setSelectSet(ctx, datastore, createDatastoreLower, cache)
+
+	network := initSpec(ctx, ComputeResourceName, NetworkProperty, cache)
+	setSelectSet(ctx, network, createNetworkLower, cache)
+
 	return []types.BaseSelectionSpec{
 		datastore,
 		host,
@@ -110,7 +121,7 @@ func createDatastoreLower(
 	cache map[string]*types.TraversalSpec,
 ) []types.BaseSelectionSpec {
 	skipDatastoreVMKey, ok := ctx.Value(IgnoreDatastoreVMKey{}).(bool)
-	if ok && skipDatastoreVMKey {
+	if (ok && skipDatastoreVMKey) || isContainmentOnly(ctx) {
 		return []types.BaseSelectionSpec{}
 	}
 
@@ -171,14 +182,20 @@ func createHostSystemLower(
 	ctx context.Context,
 	cache map[string]*types.TraversalSpec,
 ) []types.BaseSelectionSpec {
+	vm := initSpec(ctx, HostSystemName, VMProperty, cache)
+
+	if isContainmentOnly(ctx) {
+		return []types.BaseSelectionSpec{
+			vm,
+		}
+	}
+
 	datastore := initSpec(ctx, HostSystemName, DatastoreProperty, cache)
 	setSelectSet(ctx, datastore, createDatastoreLower, cache)
 
 	network := initSpec(ctx, HostSystemName, NetworkProperty, cache)
 	setSelectSet(ctx, network, createNetworkLower, cache)
 
-	vm := initSpec(ctx, HostSystemName, VMProperty, cache)
-
 	return []types.BaseSelectionSpec{
 		datastore,
 		network,
@@ -203,7 +220,7 @@ func createNetworkLower(
 	cache map[string]*types.TraversalSpec,
 ) []types.BaseSelectionSpec {
 	skipNetworkVMKey, ok := ctx.Value(IgnoreNetworkVMKey{}).(bool)
-	if ok && skipNetworkVMKey {
+	if (ok && skipNetworkVMKey) || isContainmentOnly(ctx) {
 		return []types.BaseSelectionSpec{}
 	}
 
@@ -286,6 +303,11 @@ func createVirtualMachineUpper(
 	}
 }
 
+func isContainmentOnly(ctx context.Context) bool {
+	containmentOnly, ok := ctx.Value(ContainmentOnlyKey{}).(bool)
+	return ok && containmentOnly
+}
+
 func initSelection(
 	_ context.Context,
 	name string,
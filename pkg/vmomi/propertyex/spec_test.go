@@ -0,0 +1,171 @@
+package propertyex_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	px "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/propertyex"
+	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
+)
+
+const (
+	empty       = 0
+	retrieveErr = "Retrieve: %v"
+)
+
+var entityTypes = []string{
+	"ClusterComputeResource",
+	"ComputeResource",
+	"Datacenter",
+	"Datastore",
+	"DistributedVirtualPortgroup",
+	"DistributedVirtualSwitch",
+	"Folder",
+	"HostSystem",
+	"Network",
+	"ResourcePool",
+	"VirtualMachine",
+	"VmwareDistributedVirtualSwitch",
+}
+
+func login(t *testing.T) (context.Context, *vim25.Client) {
+	t.Helper()
+
+	sim := vcsimtest.Start(t)
+	password, _ := sim.URL.User.Password()
+	c, err := sx.Login(sim.Context, sim.URL.String(), sim.URL.User.Username(), password, true)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = sx.Logout(context.WithoutCancel(sim.Context), c)
+	})
+
+	return sim.Context, c
+}
+
+func findEntity(
+	ctx context.Context,
+	t *testing.T,
+	c *vim25.Client,
+	moType string,
+	name string,
+) types.ManagedObjectReference {
+	t.Helper()
+
+	roots := []types.ManagedObjectReference{c.ServiceContent.RootFolder}
+	objects, err := px.Retrieve(ctx, c, roots, []string{moType}, []string{"name"}, false)
+	if err != nil {
+		t.Fatalf(retrieveErr, err)
+	}
+
+	for _, obj := range objects {
+		if obj.PropSet[empty].Val == name {
+			return obj.Obj
+		}
+	}
+
+	t.Fatalf("Not found %v %v", moType, name)
+	return types.ManagedObjectReference{}
+}
+
+func toLines(objects []types.ObjectContent) []byte {
+	lines := []string{}
+	for _, obj := range objects {
+		name := ""
+		if len(obj.PropSet) != empty {
+			name = fmt.Sprintf("%v", obj.PropSet[empty].Val)
+		}
+
+		lines = append(lines, fmt.Sprintf("%v %v %v\n", obj.Obj.Type, obj.Obj.Value, name))
+	}
+
+	slices.Sort(lines)
+	return []byte(strings.Join(lines, ""))
+}
+
+func TestTraverseChildFromRootFolder(t *testing.T) {
+	ctx, c := login(t)
+
+	roots := []types.ManagedObjectReference{c.ServiceContent.RootFolder}
+	objects, err := px.Retrieve(ctx, c, roots, entityTypes, []string{"name"}, false)
+	if err != nil {
+		t.Fatalf(retrieveErr, err)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/traverse_child_root.golden", toLines(objects))
+}
+
+func TestTraverseChildFromCluster(t *testing.T) {
+	ctx, c := login(t)
+
+	cluster := findEntity(ctx, t, c, "ClusterComputeResource", "DC0_C0")
+	roots := []types.ManagedObjectReference{cluster}
+	objects, err := px.Retrieve(ctx, c, roots, entityTypes, []string{"name"}, true)
+	if err != nil {
+		t.Fatalf(retrieveErr, err)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/traverse_child_cluster.golden", toLines(objects))
+}
+
+func TestTraverseChildIgnoreVMRelation(t *testing.T) {
+	ctx, c := login(t)
+	ctx = context.WithValue(ctx, px.IgnoreDatastoreVMKey{}, true)
+	ctx = context.WithValue(ctx, px.IgnoreNetworkVMKey{}, true)
+
+	roots := []types.ManagedObjectReference{findEntity(ctx, t, c, "Datastore", "LocalDS_0")}
+	objects, err := px.Retrieve(ctx, c, roots, entityTypes, []string{"name"}, true)
+	if err != nil {
+		t.Fatalf(retrieveErr, err)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/traverse_child_ignore.golden", toLines(objects))
+}
+
+func TestTraverseChildContainmentOnly(t *testing.T) {
+	ctx, c := login(t)
+	ctx = context.WithValue(ctx, px.ContainmentOnlyKey{}, true)
+
+	cluster := findEntity(ctx, t, c, "ClusterComputeResource", "DC0_C0")
+	roots := []types.ManagedObjectReference{cluster}
+	objects, err := px.Retrieve(ctx, c, roots, entityTypes, []string{"name"}, true)
+	if err != nil {
+		t.Fatalf(retrieveErr, err)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/traverse_child_containment.golden", toLines(objects))
+}
+
+func TestTraverseParentFromVirtualMachine(t *testing.T) {
+	ctx, c := login(t)
+
+	vm := findEntity(ctx, t, c, "VirtualMachine", "DC0_C0_RP0_VM0")
+	props := []types.PropertySpec{}
+	for _, moType := range entityTypes {
+		props = append(props, types.PropertySpec{Type: moType, PathSet: []string{"name"}})
+	}
+
+	req := types.RetrieveProperties{
+		SpecSet: []types.PropertyFilterSpec{{
+			ObjectSet: []types.ObjectSpec{px.TraverseParent(ctx, vm, false)},
+			PropSet:   props,
+		}},
+	}
+
+	res, err := property.DefaultCollector(c).RetrieveProperties(ctx, req)
+	if err != nil {
+		t.Fatalf("RetrieveProperties: %v", err)
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/traverse_parent_vm.golden", toLines(res.Returnval))
+}
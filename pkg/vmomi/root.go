@@ -0,0 +1,233 @@
+package vmomi
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/list"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
+)
+
+const (
+	globChars = "*?["
+	noValue   = ""
+	anyType   = ManagedEntityType("")
+)
+
+// RootSpec selects roots by MoRef ID, inventory path, name pattern or name in this order.
+// Path accepts glob pattern, and name is matched exactly.
+type RootSpec struct {
+	Type    ManagedEntityType
+	Name    string
+	Pattern string
+	Path    string
+	ID      string
+}
+
+type RootMatch struct {
+	Spec     RootSpec
+	Entities []Entity
+}
+
+type rootResolver struct {
+	client   *vim25.Client
+	entities map[ManagedEntityType][]Entity
+}
+
+// IsGlob reports whether root is selected by name pattern or path with glob pattern.
+func (s RootSpec) IsGlob() bool {
+	return strings.ContainsAny(s.Path, globChars) || s.Pattern != noValue
+}
+
+// ValidateRoot checks glob pattern of root before querying inventory.
+func ValidateRoot(s RootSpec) error {
+	if _, err := path.Match(s.Pattern, noValue); err != nil {
+		return fmt.Errorf("invalid root pattern %v: %w", s.Pattern, err)
+	}
+
+	if _, err := path.Match(s.Path, noValue); err != nil {
+		return fmt.Errorf("invalid root path %v: %w", s.Path, err)
+	}
+
+	return nil
+}
+
+// FindRoots resolves entities of each root without listing inventory
+// except for selection by name.
+func FindRoots(ctx context.Context, specs []RootSpec) ([]RootMatch, error) {
+	c, err := login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer logout(ctx, c)
+
+	r := rootResolver{
+		client:   c,
+		entities: map[ManagedEntityType][]Entity{},
+	}
+
+	matches := []RootMatch{}
+	for _, s := range specs {
+		entities, err := r.find(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+
+		matches = append(matches, RootMatch{Spec: s, Entities: entities})
+	}
+
+	return matches, nil
+}
+
+func (r *rootResolver) find(ctx context.Context, s RootSpec) ([]Entity, error) {
+	switch {
+	case s.ID != noValue:
+		return r.findByID(ctx, s)
+	case s.Path != noValue && s.IsGlob():
+		return r.findByGlobPath(ctx, s)
+	case s.Path != noValue:
+		return r.findByPath(ctx, s)
+	case s.Pattern != noValue:
+		return r.findByName(ctx, s, func(name string) (bool, error) {
+			return path.Match(s.Pattern, name)
+		})
+	default:
+		return r.findByName(ctx, s, func(name string) (bool, error) {
+			return name == s.Name, nil
+		})
+	}
+}
+
+func (r *rootResolver) findByID(ctx context.Context, s RootSpec) ([]Entity, error) {
+	if s.Type == anyType {
+		return nil, fmt.Errorf("type is required for root id %v", s.ID)
+	}
+
+	defer ObservePhase(ctx, PhaseInventory, time.Now())
+
+	ref := types.ManagedObjectReference{Type: string(s.Type), Value: s.ID}
+	return r.retrieveEntities(ctx, s, ref)
+}
+
+func (r *rootResolver) findByPath(ctx context.Context, s RootSpec) ([]Entity, error) {
+	defer ObservePhase(ctx, PhaseInventory, time.Now())
+
+	si := object.NewSearchIndex(r.client)
+	found, err := sx.ExecCallAPI(
+		ctx,
+		func(cctx context.Context) (object.Reference, error) {
+			return si.FindByInventoryPath(cctx, s.Path)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return []Entity{}, nil
+	}
+
+	return r.retrieveEntities(ctx, s, found.Reference())
+}
+
+func (r *rootResolver) findByGlobPath(ctx context.Context, s RootSpec) ([]Entity, error) {
+	defer ObservePhase(ctx, PhaseInventory, time.Now())
+
+	// Finder traverses only the folders matched with each path element.
+	finder := find.NewFinder(r.client)
+	elements, err := sx.ExecCallAPI(
+		ctx,
+		func(cctx context.Context) ([]list.Element, error) {
+			return finder.ManagedObjectList(cctx, s.Path)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := []Entity{}
+	for _, e := range elements {
+		ref := e.Object.Reference()
+		entity := Entity{
+			ID:   ref.Value,
+			Name: path.Base(e.Path),
+			Type: ManagedEntityType(ref.Type),
+		}
+
+		if s.Type == anyType || entity.Type == s.Type {
+			entities = append(entities, entity)
+		}
+	}
+
+	return entities, nil
+}
+
+func (r *rootResolver) findByName(
+	ctx context.Context,
+	s RootSpec,
+	match func(name string) (bool, error),
+) ([]Entity, error) {
+	all, ok := r.entities[s.Type]
+	if !ok {
+		roots := []types.ManagedObjectReference{r.client.ServiceContent.RootFolder}
+		managed, err := getEntities(ctx, r.client, roots, []string{string(s.Type)}, false)
+		if err != nil {
+			return nil, err
+		}
+
+		all = *toEntitiesFromManageds(managed)
+		r.entities[s.Type] = all
+	}
+
+	entities := []Entity{}
+	for _, e := range all {
+		if matched, err := match(e.Name); err != nil {
+			return nil, err
+		} else if matched {
+			entities = append(entities, e)
+		}
+	}
+
+	return entities, nil
+}
+
+func (r *rootResolver) retrieveEntities(
+	ctx context.Context,
+	s RootSpec,
+	ref types.ManagedObjectReference,
+) ([]Entity, error) {
+	if s.Type != anyType && ref.Type != string(s.Type) {
+		return []Entity{}, nil
+	}
+
+	pc := property.DefaultCollector(r.client)
+
+	var me mo.ManagedEntity
+	_, err := sx.ExecCallAPI(
+		ctx,
+		func(cctx context.Context) (int, error) {
+			return 0, pc.RetrieveOne(cctx, ref, []string{namePath}, &me)
+		},
+	)
+	if fault.Is(err, &types.ManagedObjectNotFound{}) {
+		return []Entity{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []Entity{{ID: ref.Value, Name: me.Name, Type: ManagedEntityType(ref.Type)}}, nil
+}
@@ -0,0 +1,57 @@
+package vmomi_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const datacenters = 2
+
+func TestFindRoots(t *testing.T) {
+	sim := vcsimtest.Start(t, vcsimtest.WithDatacenter(datacenters))
+
+	cluster := vmomi.ManagedEntityTypeClusterComputeResource
+	specs := []vmomi.RootSpec{
+		{Path: "/DC1/host/DC1_C0"},
+		{Path: "/DC*/host/*_C0"},
+		{Type: cluster, ID: "domain-c28"},
+		{Type: vmomi.ManagedEntityTypeHostSystem, Pattern: "DC0_C0_H*"},
+		{Type: vmomi.ManagedEntityTypeHostSystem, Name: "DC0_C0_H*"},
+		{Type: vmomi.ManagedEntityTypeDatastore, Name: "LocalDS_0"},
+		{Type: cluster, Path: "/DC0/host/DC0_H0"},
+		{Type: cluster, ID: "domain-c0"},
+	}
+
+	matches, err := vmomi.FindRoots(sim.Context, specs)
+	if err != nil {
+		t.Fatalf("FindRoots: %v", err)
+	}
+
+	lines := []string{}
+	for _, m := range matches {
+		lines = append(lines, string(entityLines(m.Entities)), "--\n")
+	}
+
+	vcsimtest.AssertGolden(t, "testdata/root.golden", []byte(strings.Join(lines, "")))
+}
+
+func TestValidateRoot(t *testing.T) {
+	tests := []struct {
+		spec  vmomi.RootSpec
+		valid bool
+	}{
+		{vmomi.RootSpec{Name: "test["}, true},
+		{vmomi.RootSpec{Pattern: "test*"}, true},
+		{vmomi.RootSpec{Pattern: "test["}, false},
+		{vmomi.RootSpec{Path: "/DC0/host/test["}, false},
+	}
+
+	for _, test := range tests {
+		if err := vmomi.ValidateRoot(test.spec); (err == nil) != test.valid {
+			t.Errorf("ValidateRoot(%v) = %v", test.spec, err)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package vmomi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
+)
+
+const (
+	defaultTargetLatency    = 5 * time.Second
+	empty64                 = int64(0)
+	grow                    = 2
+	halve                   = 2
+	maxQueryMetricsProperty = "querySpec.size"
+	minConcurrency          = int64(1)
+	minMetricChunkSize      = 1
+	// Try the larger metric chunk size after queries without split.
+	metricChunkGrowQueries = 10
+	unlimited              = 0
+)
+
+type SchedulerState struct {
+	Concurrency     int64
+	MetricChunkSize int
+}
+
+// Learned from previous queries to start next scrape with them.
+var schedulerState = struct {
+	rock  sync.Mutex
+	state SchedulerState
+	// succeeded counts queries without split since metric chunk size is changed.
+	succeeded int
+}{}
+
+type concurrencyLimiter struct {
+	limit    int64
+	maxLimit int64
+	inflight int64
+	target   time.Duration
+	released chan struct{}
+	rock     sync.Mutex
+}
+
+func GetSchedulerState() SchedulerState {
+	schedulerState.rock.Lock()
+	defer schedulerState.rock.Unlock()
+
+	return schedulerState.state
+}
+
+func learnConcurrency(concurrency int64) {
+	schedulerState.rock.Lock()
+	defer schedulerState.rock.Unlock()
+
+	schedulerState.state.Concurrency = concurrency
+}
+
+func learnMetricChunkSize(size int) {
+	schedulerState.rock.Lock()
+	defer schedulerState.rock.Unlock()
+
+	schedulerState.succeeded = empty
+	if exceeds(size, schedulerState.state.MetricChunkSize) {
+		return
+	}
+
+	schedulerState.state.MetricChunkSize = size
+}
+
+// learnQuerySucceeded grows the learned metric chunk size after some queries without split
+// because the limit may be raised in vSphere server.
+func learnQuerySucceeded(ctx context.Context) {
+	configured := getConfiguredMetricChunkSize(ctx)
+
+	schedulerState.rock.Lock()
+	defer schedulerState.rock.Unlock()
+
+	if schedulerState.state.MetricChunkSize == unlimited {
+		return
+	}
+
+	schedulerState.succeeded++
+	if schedulerState.succeeded < metricChunkGrowQueries {
+		return
+	}
+
+	schedulerState.succeeded = empty
+	schedulerState.state.MetricChunkSize *= grow
+	if isFull(schedulerState.state.MetricChunkSize, configured) {
+		// Not learned because configured size is used.
+		schedulerState.state.MetricChunkSize = unlimited
+	}
+}
+
+func newConcurrencyLimiter(ctx context.Context) *concurrencyLimiter {
+	maxLimit := getMaxConcurrency(ctx)
+
+	limit := GetSchedulerState().Concurrency
+	if limit < minConcurrency || limit > maxLimit {
+		limit = maxLimit
+	}
+
+	// Report the effective limit even if latency tuning is disabled.
+	learnConcurrency(limit)
+
+	return &concurrencyLimiter{
+		limit:    limit,
+		maxLimit: maxLimit,
+		inflight: empty64,
+		target:   getTargetLatency(ctx),
+		released: make(chan struct{}),
+	}
+}
+
+func (l *concurrencyLimiter) acquire(ctx context.Context) error {
+	for {
+		l.rock.Lock()
+		if l.inflight < l.limit {
+			l.inflight++
+			l.rock.Unlock()
+			return nil
+		}
+
+		released := l.released
+		l.rock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+func (l *concurrencyLimiter) release(latency time.Duration, err error) {
+	l.rock.Lock()
+	defer l.rock.Unlock()
+
+	l.inflight--
+	l.adjust(latency, err)
+
+	// Wake up all waiters to re-check the limit.
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+func (l *concurrencyLimiter) adjust(latency time.Duration, err error) {
+	if l.target == unlimited {
+		return
+	}
+
+	// Additive increase and multiplicative decrease.
+	slow := latency > l.target || errors.Is(err, context.DeadlineExceeded)
+	if slow {
+		l.limit = max(l.limit/halve, minConcurrency)
+	} else if l.limit < l.maxLimit {
+		l.limit++
+	}
+
+	learnConcurrency(l.limit)
+}
+
+func querySpecs(
+	ctx context.Context,
+	pm *performance.Manager,
+	limiter *concurrencyLimiter,
+	specs []types.PerfQuerySpec,
+) ([]types.BasePerfEntityMetricBase, error) {
+	entityMetrics, err := queryLimited(ctx, pm, limiter, specs)
+	if err == nil || !isMaxQueryMetricsFault(err) {
+		return entityMetrics, err
+	}
+
+	count := countMetrics(specs)
+	if count <= minMetricChunkSize {
+		return nil, err
+	}
+
+	// Split the chunk rejected by vpxd.stats.maxQueryMetrics.
+	size := count/halve + count%halve
+	learnMetricChunkSize(size)
+	slog.DebugContext(ctx, "Split", "metricCount", count, "metricChunkSize", size)
+
+	merged := []types.BasePerfEntityMetricBase{}
+	for _, chunk := range packSpecs(specs, len(specs), size) {
+		m, err := querySpecs(ctx, pm, limiter, chunk)
+		if err != nil {
+			return nil, err
+		}
+
+		merged = append(merged, m...)
+	}
+
+	return merged, nil
+}
+
+func queryLimited(
+	ctx context.Context,
+	pm *performance.Manager,
+	limiter *concurrencyLimiter,
+	specs []types.PerfQuerySpec,
+) ([]types.BasePerfEntityMetricBase, error) {
+	if err := limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	entityMetrics, err := sx.ExecCallAPI(
+		ctx,
+		func(cctx context.Context) ([]types.BasePerfEntityMetricBase, error) {
+			return pm.Query(cctx, specs)
+		},
+	)
+
+	limiter.release(time.Since(started), err)
+
+	return entityMetrics, err
+}
+
+func packSpecs(
+	specs []types.PerfQuerySpec,
+	entitySize int,
+	metricSize int,
+) [][]types.PerfQuerySpec {
+	chunks := [][]types.PerfQuerySpec{}
+	chunk := []types.PerfQuerySpec{}
+	count := empty
+	for _, spec := range splitSpecs(specs, metricSize) {
+		n := len(spec.MetricId)
+		if len(chunk) != empty &&
+			(isFull(len(chunk), entitySize) || exceeds(count+n, metricSize)) {
+			chunks = append(chunks, chunk)
+			chunk = []types.PerfQuerySpec{}
+			count = empty
+		}
+
+		chunk = append(chunk, spec)
+		count += n
+	}
+
+	if len(chunk) != empty {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
+
+func splitSpecs(specs []types.PerfQuerySpec, metricSize int) []types.PerfQuerySpec {
+	if metricSize == unlimited {
+		return specs
+	}
+
+	split := []types.PerfQuerySpec{}
+	for _, spec := range specs {
+		for ids := range slices.Chunk(spec.MetricId, metricSize) {
+			s := spec
+			s.MetricId = ids
+			split = append(split, s)
+		}
+	}
+
+	return split
+}
+
+func countMetrics(specs []types.PerfQuerySpec) int {
+	count := empty
+	for _, spec := range specs {
+		count += len(spec.MetricId)
+	}
+
+	return count
+}
+
+func exceeds(n int, limit int) bool {
+	return limit != unlimited && n > limit
+}
+
+func isFull(n int, limit int) bool {
+	return limit != unlimited && n >= limit
+}
+
+func isMaxQueryMetricsFault(err error) bool {
+	// vCenter 6.5 or later reports "Request processing is restricted by administrator".
+	if fault.Is(err, &types.RestrictedByAdministrator{}) {
+		return true
+	}
+
+	var invalid *types.InvalidArgument
+	if _, ok := fault.As(err, &invalid); !ok {
+		return false
+	}
+
+	return invalid.InvalidProperty == maxQueryMetricsProperty
+}
+
+func getConfiguredMetricChunkSize(ctx context.Context) int {
+	size, ok := ctx.Value(flag.TargetMetricChunkSizeKey{}).(int)
+	if !ok || size < unlimited {
+		return unlimited
+	}
+
+	return size
+}
+
+func getMetricChunkSize(ctx context.Context) int {
+	size := getConfiguredMetricChunkSize(ctx)
+
+	learned := GetSchedulerState().MetricChunkSize
+	if learned == unlimited {
+		return size
+	}
+
+	if size == unlimited {
+		return learned
+	}
+
+	return min(size, learned)
+}
+
+func getTargetLatency(ctx context.Context) time.Duration {
+	seconds, ok := ctx.Value(flag.TargetQueryLatencyKey{}).(float64)
+	if !ok || seconds < unlimited {
+		return defaultTargetLatency
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
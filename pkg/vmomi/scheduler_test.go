@@ -0,0 +1,182 @@
+package vmomi
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+)
+
+const (
+	oneEntity    = 1
+	oneMetric    = 1
+	twoEntities  = 2
+	twoMetrics   = 2
+	threeMetrics = 3
+	fiveMetrics  = 5
+	concurrency  = 4
+	noTuning     = float64(0)
+)
+
+const (
+	host1Metrics3 = "host-1:3"
+	host1Metrics2 = "host-1:2"
+	host2Metrics2 = "host-2:2"
+	host3Metrics1 = "host-3:1"
+)
+
+func newSpec(entity string, metricCount int) types.PerfQuerySpec {
+	ids := []types.PerfMetricId{}
+	for i := range metricCount {
+		ids = append(ids, types.PerfMetricId{CounterId: int32(i)})
+	}
+
+	return types.PerfQuerySpec{
+		Entity:   types.ManagedObjectReference{Type: "HostSystem", Value: entity},
+		MetricId: ids,
+	}
+}
+
+// toShape formats each spec as entity and count of metric IDs.
+func toShape(specs []types.PerfQuerySpec) []string {
+	shape := []string{}
+	for _, s := range specs {
+		shape = append(shape, fmt.Sprintf("%v:%v", s.Entity.Value, len(s.MetricId)))
+	}
+
+	return shape
+}
+
+func TestSplitSpecs(t *testing.T) {
+	specs := []types.PerfQuerySpec{newSpec("host-1", fiveMetrics), newSpec("host-2", twoMetrics)}
+
+	tests := []struct {
+		metricSize int
+		expected   []string
+	}{
+		{unlimited, []string{"host-1:5", host2Metrics2}},
+		{twoMetrics, []string{host1Metrics2, host1Metrics2, "host-1:1", host2Metrics2}},
+		{fiveMetrics, []string{"host-1:5", host2Metrics2}},
+	}
+
+	for _, test := range tests {
+		split := splitSpecs(specs, test.metricSize)
+		if shape := toShape(split); !slices.Equal(shape, test.expected) {
+			t.Errorf("splitSpecs(%v) = %v", test.metricSize, shape)
+		}
+
+		if countMetrics(split) != countMetrics(specs) {
+			t.Errorf("splitSpecs(%v) lost metric IDs", test.metricSize)
+		}
+	}
+}
+
+func TestPackSpecs(t *testing.T) {
+	specs := []types.PerfQuerySpec{
+		newSpec("host-1", threeMetrics),
+		newSpec("host-2", twoMetrics),
+		newSpec("host-3", oneMetric),
+	}
+
+	tests := []struct {
+		name       string
+		entitySize int
+		metricSize int
+		expected   [][]string
+	}{
+		{
+			"unlimited",
+			unlimited,
+			unlimited,
+			[][]string{{host1Metrics3, host2Metrics2, host3Metrics1}},
+		},
+		{
+			"entity size",
+			twoEntities,
+			unlimited,
+			[][]string{{host1Metrics3, host2Metrics2}, {host3Metrics1}},
+		},
+		{
+			"metric size",
+			unlimited,
+			threeMetrics,
+			[][]string{{host1Metrics3}, {host2Metrics2, host3Metrics1}},
+		},
+		{
+			"split entity",
+			oneEntity,
+			twoMetrics,
+			[][]string{{host1Metrics2}, {"host-1:1"}, {host2Metrics2}, {host3Metrics1}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shapes := [][]string{}
+			for _, chunk := range packSpecs(specs, test.entitySize, test.metricSize) {
+				shapes = append(shapes, toShape(chunk))
+			}
+
+			assertShapes(t, shapes, test.expected)
+		})
+	}
+}
+
+func assertShapes(t *testing.T, actual [][]string, expected [][]string) {
+	t.Helper()
+
+	if !slices.EqualFunc(actual, expected, slices.Equal) {
+		t.Errorf("packSpecs = %v, expected %v", actual, expected)
+	}
+}
+
+func TestConcurrencyLimiterWithoutTuning(t *testing.T) {
+	ctx := context.WithValue(t.Context(), flag.TargetMaxConcurrency{}, concurrency)
+	ctx = context.WithValue(ctx, flag.TargetQueryLatencyKey{}, noTuning)
+	t.Cleanup(func() { learnConcurrency(empty64) })
+
+	l := newConcurrencyLimiter(ctx)
+	if l.limit != concurrency {
+		t.Errorf("Limit %v", l.limit)
+	}
+
+	if state := GetSchedulerState(); state.Concurrency != concurrency {
+		t.Errorf("Concurrency %v", state.Concurrency)
+	}
+}
+
+func TestLearnQuerySucceeded(t *testing.T) {
+	ctx := context.WithValue(t.Context(), flag.TargetMetricChunkSizeKey{}, fiveMetrics)
+	t.Cleanup(func() {
+		schedulerState.state.MetricChunkSize = unlimited
+		schedulerState.succeeded = empty
+	})
+
+	learnMetricChunkSize(twoMetrics)
+	for range metricChunkGrowQueries - oneMetric {
+		learnQuerySucceeded(ctx)
+	}
+
+	if size := getMetricChunkSize(ctx); size != twoMetrics {
+		t.Errorf("Grew before %v queries: %v", metricChunkGrowQueries, size)
+	}
+
+	learnQuerySucceeded(ctx)
+	if size := getMetricChunkSize(ctx); size != twoMetrics*grow {
+		t.Errorf("Not grew: %v", size)
+	}
+
+	for range metricChunkGrowQueries {
+		learnQuerySucceeded(ctx)
+	}
+
+	// Configured size is used instead of learned size exceeding it.
+	state := GetSchedulerState()
+	if size := getMetricChunkSize(ctx); size != fiveMetrics || state.MetricChunkSize != unlimited {
+		t.Errorf("Not reset: %v, %v", size, state.MetricChunkSize)
+	}
+}
@@ -0,0 +1,321 @@
+package vmomi
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
+)
+
+const (
+	counterIDBase      = 10
+	counterIDBitSize   = 32
+	counterNameMinPart = 3
+	counterNameSep     = "."
+	nextPart           = 1
+)
+
+type SeriesQuery struct {
+	Entities  []Entity
+	Counters  []CounterInfo
+	Instances []string
+	Interval  int32
+	Start     *time.Time
+	End       *time.Time
+	Lookback  time.Duration
+	MaxSample int32
+}
+
+// ParseCounter parses counter ID or name such as `cpu.usage.average`.
+func ParseCounter(value string) (*CounterInfo, error) {
+	if id, err := strconv.ParseInt(value, counterIDBase, counterIDBitSize); err == nil {
+		return &CounterInfo{ID: int32(id)}, nil
+	}
+
+	parts := strings.Split(value, counterNameSep)
+	if len(parts) < counterNameMinPart {
+		return nil, fmt.Errorf("invalid counter %v", value)
+	}
+
+	last := len(parts) - nextPart
+	return &CounterInfo{
+		ID:     initCounterKey,
+		Group:  parts[first],
+		Name:   strings.Join(parts[first+nextPart:last], counterNameSep),
+		Rollup: parts[last],
+	}, nil
+}
+
+// QuerySeries returns all samples in the period instead of the latest sample.
+func QuerySeries(ctx context.Context, query SeriesQuery) ([]Metric, error) {
+	c, err := login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer logout(ctx, c)
+
+	p, err := getPerformanceManager(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	query.Counters = ComplementCounterInfoList(ctx, *p, query.Counters)
+	if len(query.Counters) == empty {
+		return nil, errors.New("not found counters")
+	}
+
+	age := periodAge(query)
+	if err := resolvePeriod(ctx, c, &query); err != nil {
+		return nil, err
+	}
+
+	pm := performance.NewManager(c)
+	specs, err := createSeriesSpecs(ctx, pm, p.HistoricalInterval, query, age)
+	if err != nil {
+		return nil, err
+	}
+
+	entityMetrics, err := sx.ExecCallAPI(
+		ctx,
+		func(cctx context.Context) ([]types.BasePerfEntityMetricBase, error) {
+			return pm.Query(cctx, specs)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return toSeriesMetrics(p, query.Entities, entityMetrics), nil
+}
+
+// periodAge returns how long ago the period starts, or zero for the latest samples.
+func periodAge(query SeriesQuery) time.Duration {
+	if query.Start != nil {
+		return time.Since(*query.Start)
+	}
+
+	return query.Lookback
+}
+
+func resolvePeriod(ctx context.Context, c *vim25.Client, query *SeriesQuery) error {
+	if query.Start != nil || query.Lookback <= time.Duration(empty) {
+		return nil
+	}
+
+	// Use server clock because client clock may be skewed.
+	serverClock, err := sx.ExecCallAPI(
+		ctx,
+		func(cctx context.Context) (*time.Time, error) {
+			return methods.GetCurrentTime(cctx, c)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	start := serverClock.Add(-query.Lookback)
+	query.Start = &start
+	return nil
+}
+
+func createSeriesSpecs(
+	ctx context.Context,
+	pm *performance.Manager,
+	intervalIDs []types.PerfInterval,
+	query SeriesQuery,
+	age time.Duration,
+) ([]types.PerfQuerySpec, error) {
+	defer ObservePhase(ctx, PhaseAvailableMetric, time.Now())
+
+	specs := []types.PerfQuerySpec{}
+	for _, e := range query.Entities {
+		spec, err := createSeriesSpec(ctx, pm, intervalIDs, query, e, age)
+		if err != nil {
+			return nil, err
+		}
+
+		if spec != nil {
+			specs = append(specs, *spec)
+		}
+	}
+
+	if len(specs) == empty {
+		return nil, errors.New("not found available metrics")
+	}
+
+	return specs, nil
+}
+
+func createSeriesSpec(
+	ctx context.Context,
+	pm *performance.Manager,
+	intervalIDs []types.PerfInterval,
+	query SeriesQuery,
+	e Entity,
+	age time.Duration,
+) (*types.PerfQuerySpec, error) {
+	ref := types.ManagedObjectReference{Type: string(e.Type), Value: e.ID}
+
+	interval, err := seriesInterval(ctx, pm, intervalIDs, ref, query.Interval, age)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.MaxSample > empty32 && !interval.Current {
+		// Server ignores MaxSample for historical statistics.
+		slog.WarnContext(ctx, "Ignored max samples", "entity", e, "interval", interval.ID)
+	}
+
+	metrics, err := sx.ExecCallAPI(
+		ctx,
+		func(cctx context.Context) (performance.MetricList, error) {
+			return pm.AvailableMetric(cctx, ref, interval.ID)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := filterInstance(filterPerfMetricID(&query.Counters, metrics), query.Instances)
+	if len(ids) == empty {
+		return nil, nil
+	}
+
+	return &types.PerfQuerySpec{
+		Entity:     ref,
+		MaxSample:  query.MaxSample,
+		MetricId:   ids,
+		IntervalId: interval.ID,
+		StartTime:  query.Start,
+		EndTime:    query.End,
+	}, nil
+}
+
+func seriesInterval(
+	ctx context.Context,
+	pm *performance.Manager,
+	intervalIDs []types.PerfInterval,
+	ref types.ManagedObjectReference,
+	interval int32,
+	age time.Duration,
+) (*IntervalID, error) {
+	if interval != empty32 {
+		historical := slices.ContainsFunc(intervalIDs, func(i types.PerfInterval) bool {
+			return i.SamplingPeriod == interval
+		})
+		return &IntervalID{ID: interval, Current: !historical}, nil
+	}
+
+	intervals, err := getIntervalIDs(ctx, pm, intervalIDs, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	intervalID := coveringIntervalID(intervals, age)
+	if intervalID == nil {
+		return nil, fmt.Errorf("not supported statistics %v(%v)", ref.Type, ref.Value)
+	}
+
+	return intervalID, nil
+}
+
+func filterInstance(ids []types.PerfMetricId, instances []string) []types.PerfMetricId {
+	if len(instances) == empty {
+		return ids
+	}
+
+	return slices.DeleteFunc(ids, func(id types.PerfMetricId) bool {
+		return !slices.Contains(instances, id.Instance)
+	})
+}
+
+func toSeriesMetrics(
+	p *mo.PerformanceManager,
+	entities []Entity,
+	entityMetrics []types.BasePerfEntityMetricBase,
+) []Metric {
+	metrics := []Metric{}
+	for _, base := range entityMetrics {
+		entityMetric, ok := base.(*types.PerfEntityMetric)
+		if !ok {
+			continue
+		}
+
+		entity := findSeriesEntity(entities, entityMetric.Entity)
+		for _, v := range entityMetric.Value {
+			metrics = append(metrics, toSeries(p, entity, entityMetric, v)...)
+		}
+	}
+
+	slices.SortFunc(metrics, compareSeries)
+	return metrics
+}
+
+func toSeries(
+	p *mo.PerformanceManager,
+	entity Entity,
+	entityMetric *types.PerfEntityMetric,
+	v types.BasePerfMetricSeries,
+) []Metric {
+	metricSeries, ok := v.(*types.PerfMetricIntSeries)
+	if !ok {
+		return nil
+	}
+
+	cnt := findCounter(*p, metricSeries.Id.CounterId)
+	if cnt == nil {
+		return nil
+	}
+
+	metrics := []Metric{}
+	for idx, s := range entityMetric.SampleInfo {
+		if idx >= len(metricSeries.Value) {
+			break
+		}
+
+		metrics = append(metrics, Metric{
+			Entity:    entity,
+			Counter:   *cnt,
+			Instance:  metricSeries.Id.Instance,
+			Timestamp: s.Timestamp,
+			Value:     metricSeries.Value[idx],
+			Interval:  s.Interval,
+		})
+	}
+
+	return metrics
+}
+
+func findSeriesEntity(entities []Entity, ref types.ManagedObjectReference) Entity {
+	for _, e := range entities {
+		if string(e.Type) == ref.Type && e.ID == ref.Value {
+			return e
+		}
+	}
+
+	return Entity{ID: ref.Value, Name: ref.Value, Type: ManagedEntityType(ref.Type)}
+}
+
+func compareSeries(a, b Metric) int {
+	return cmp.Or(
+		cmp.Compare(a.Entity.Type, b.Entity.Type),
+		cmp.Compare(a.Entity.ID, b.Entity.ID),
+		cmp.Compare(a.Counter.ID, b.Counter.ID),
+		cmp.Compare(a.Instance, b.Instance),
+		a.Timestamp.Compare(b.Timestamp),
+	)
+}
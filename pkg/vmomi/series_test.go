@@ -0,0 +1,90 @@
+package vmomi_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+const (
+	counterID = 2
+	maxSample = 2
+	instance  = "0"
+)
+
+func TestParseCounter(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected vmomi.CounterInfo
+	}{
+		{"2", vmomi.CounterInfo{ID: counterID}},
+		{"cpu.usage.average", vmomi.CounterInfo{Group: "cpu", Name: "usage", Rollup: "average"}},
+		{"a.b.c.none", vmomi.CounterInfo{Group: "a", Name: "b.c", Rollup: "none"}},
+	}
+
+	for _, test := range tests {
+		c, err := vmomi.ParseCounter(test.value)
+		if err != nil || *c != test.expected {
+			t.Errorf("ParseCounter(%v) = %v, %v", test.value, c, err)
+		}
+	}
+
+	if _, err := vmomi.ParseCounter("cpu.usage"); err == nil {
+		t.Error("Accepted invalid counter")
+	}
+}
+
+func TestQuerySeries(t *testing.T) {
+	sim := vcsimtest.Start(t)
+	counters := findCounters(t, sim, "cpu.usage.average")
+	hosts, err := vmomi.GetEntityFromRoot(
+		sim.Context,
+		[]vmomi.ManagedEntityType{vmomi.ManagedEntityTypeHostSystem},
+	)
+	if err != nil {
+		t.Fatalf("GetEntityFromRoot: %v", err)
+	}
+
+	query := vmomi.SeriesQuery{
+		Entities:  []vmomi.Entity{findEntity(t, *hosts, "DC0_H0")},
+		Counters:  []vmomi.CounterInfo{{Group: "cpu", Name: "usage", Rollup: "average"}},
+		Instances: []string{instance},
+		Lookback:  lookback,
+		MaxSample: maxSample,
+	}
+
+	metrics, err := vmomi.QuerySeries(sim.Context, query)
+	if err != nil {
+		t.Fatalf("QuerySeries: %v", err)
+	}
+
+	if len(metrics) != maxSample {
+		t.Fatalf("Unexpected sample count %v", len(metrics))
+	}
+
+	assertSeries(t, metrics, query.Entities[empty], counters[empty])
+}
+
+func assertSeries(
+	t *testing.T,
+	metrics []vmomi.Metric,
+	entity vmomi.Entity,
+	counter vmomi.CounterInfo,
+) {
+	t.Helper()
+
+	for _, m := range metrics {
+		if m.Entity != entity || m.Counter != counter || m.Instance != instance {
+			t.Errorf("Unexpected sample %v", m)
+		}
+	}
+
+	sorted := slices.IsSortedFunc(metrics, func(a, b vmomi.Metric) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	if !sorted {
+		t.Error("Samples are not sorted by timestamp")
+	}
+}
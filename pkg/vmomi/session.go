@@ -3,6 +3,9 @@ package vmomi
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/vmware/govmomi/vim25"
 
@@ -11,26 +14,74 @@ import (
 )
 
 type ConnInfo struct {
-	URL         string
-	User        string
-	Password    string
-	NoVerifySSL bool
+	URL           string
+	Credential    CredentialProvider
+	NoVerifySSL   bool
+	CAFile        string
+	Thumbprint    string
+	TLSServerName string
+	Transport     *sx.TransportOptions
+	RecordDir     string
+	ReplayDir     string
+}
+
+type TransportKey struct{}
+
+type LoginError struct {
+	Err error
+}
+
+func (e *LoginError) Error() string {
+	return fmt.Sprintf("login failed: %v", e.Err)
+}
+
+func (e *LoginError) Unwrap() error {
+	return e.Err
 }
 
 func login(ctx context.Context) (*vim25.Client, error) {
+	defer ObservePhase(ctx, PhaseLogin, time.Now())
+
 	info, err := GetTarget(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	c, err := sx.Login(ctx, info.URL, info.User, info.Password, info.NoVerifySSL)
+	cred, err := info.Credential.Credential(ctx)
 	if err != nil {
-		return nil, err
+		return nil, &LoginError{Err: err}
+	}
+
+	c, err := sx.Login(
+		ctx,
+		info.URL,
+		cred.User,
+		cred.Password,
+		info.NoVerifySSL,
+		sx.WithCAFile(info.CAFile),
+		sx.WithThumbprint(info.Thumbprint),
+		sx.WithServerName(info.TLSServerName),
+		sx.WithTransport(info.Transport),
+		sx.WithRecordDir(info.RecordDir),
+		sx.WithReplayDir(info.ReplayDir),
+		sx.WithCredential(toCredentialFunc(info.Credential)),
+	)
+	if err != nil {
+		return nil, &LoginError{Err: err}
 	}
 
 	return c, nil
 }
 
+func logout(ctx context.Context, c *vim25.Client) {
+	defer ObservePhase(ctx, PhaseLogin, time.Now())
+
+	// Release session even if the caller is canceled or exceeded deadline.
+	if err := sx.Logout(context.WithoutCancel(ctx), c); err != nil {
+		slog.WarnContext(ctx, "Could not logout", "error", err)
+	}
+}
+
 func GetTarget(ctx context.Context) (i *ConnInfo, err error) {
 	url, ok := ctx.Value(flag.TargetURLKey{}).(string)
 	if !ok {
@@ -47,17 +98,80 @@ func GetTarget(ctx context.Context) (i *ConnInfo, err error) {
 		return nil, errors.New("target_password not found in context")
 	}
 
+	passwordFile, ok := ctx.Value(flag.TargetPasswordFileKey{}).(string)
+	if !ok {
+		return nil, errors.New("target_password_file not found in context")
+	}
+
 	noVerifySSL, ok := ctx.Value(flag.TargetNoVerifySSLKey{}).(bool)
 	if !ok {
 		return nil, errors.New("target_no_verify_ssl not found in context")
 	}
 
+	provider, ok := ctx.Value(CredentialProviderKey{}).(CredentialProvider)
+	if !ok {
+		provider = getFlagCredentialProvider(user, password, passwordFile)
+	}
+
 	c := ConnInfo{
 		URL:         url,
-		User:        user,
-		Password:    password,
+		Credential:  provider,
 		NoVerifySSL: noVerifySSL,
 	}
 
+	if err := setClientInfo(ctx, &c); err != nil {
+		return nil, err
+	}
+
 	return &c, nil
 }
+
+func setClientInfo(ctx context.Context, c *ConnInfo) error {
+	caFile, ok := ctx.Value(flag.TargetCAFileKey{}).(string)
+	if !ok {
+		return errors.New("target_ca_file not found in context")
+	}
+
+	thumbprint, ok := ctx.Value(flag.TargetThumbprintKey{}).(string)
+	if !ok {
+		return errors.New("target_thumbprint not found in context")
+	}
+
+	tlsServerName, ok := ctx.Value(flag.TargetTLSServerNameKey{}).(string)
+	if !ok {
+		return errors.New("target_tls_server_name not found in context")
+	}
+
+	c.CAFile = caFile
+	c.Thumbprint = thumbprint
+	c.TLSServerName = tlsServerName
+
+	if transport, ok := ctx.Value(TransportKey{}).(*sx.TransportOptions); ok {
+		c.Transport = transport
+	}
+
+	// Optional because only for debugging.
+	if recordDir, ok := ctx.Value(flag.TargetRecordDirKey{}).(string); ok {
+		c.RecordDir = recordDir
+	}
+
+	if replayDir, ok := ctx.Value(flag.TargetReplayDirKey{}).(string); ok {
+		c.ReplayDir = replayDir
+	}
+
+	return nil
+}
+
+func getFlagCredentialProvider(user, password, passwordFile string) CredentialProvider {
+	if passwordFile != "" {
+		return &FileCredentialProvider{
+			User:         user,
+			PasswordFile: passwordFile,
+		}
+	}
+
+	return &StaticCredentialProvider{
+		User:     user,
+		Password: password,
+	}
+}
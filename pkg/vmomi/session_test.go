@@ -0,0 +1,81 @@
+package vmomi_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+	"github.com/9506hqwy/vmomi-exporter/pkg/vmomi"
+)
+
+// Unreachable address to ensure that responses are served without network.
+const offlineHost = "127.0.0.1:1"
+
+func assertRedacted(t *testing.T, dir string, password string) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.xml"))
+	if err != nil || len(files) == empty {
+		t.Fatalf("Not found recorded files: %v", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+
+		if bytes.Contains(data, []byte(">"+password+"<")) {
+			t.Errorf("Password is not redacted in %v", file)
+		}
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	sim := vcsimtest.Start(t)
+	dir := t.TempDir()
+
+	recordCtx := context.WithValue(sim.Context, flag.TargetRecordDirKey{}, dir)
+	recorded, err := vmomi.GetEntityFromRoot(recordCtx, vmomi.ManagedEntityTypeValues())
+	if err != nil {
+		t.Fatalf("GetEntityFromRoot: %v", err)
+	}
+
+	password, _ := sim.URL.User.Password()
+	assertRedacted(t, dir, password)
+
+	offline := *sim.URL
+	offline.Host = offlineHost
+	replayCtx := vcsimtest.WithTarget(t.Context(), &offline)
+	replayCtx = context.WithValue(replayCtx, flag.TargetReplayDirKey{}, dir)
+	replayed, err := vmomi.GetEntityFromRoot(replayCtx, vmomi.ManagedEntityTypeValues())
+	if err != nil {
+		t.Fatalf("GetEntityFromRoot: %v", err)
+	}
+
+	if !bytes.Equal(entityLines(*recorded), entityLines(*replayed)) {
+		t.Error("Replayed entities differ from recorded entities")
+	}
+}
+
+func TestLoginCredentialError(t *testing.T) {
+	sim := vcsimtest.Start(t)
+
+	provider := &vmomi.FileCredentialProvider{
+		User:         sim.URL.User.Username(),
+		PasswordFile: filepath.Join(t.TempDir(), "none"),
+	}
+	ctx := context.WithValue(sim.Context, vmomi.CredentialProviderKey{}, provider)
+
+	_, err := vmomi.GetEntityFromRoot(ctx, vmomi.ManagedEntityTypeValues())
+
+	var loginErr *vmomi.LoginError
+	if !errors.As(err, &loginErr) {
+		t.Errorf("Not login error %v", err)
+	}
+}
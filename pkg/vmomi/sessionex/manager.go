@@ -12,19 +12,107 @@ import (
 	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
 )
 
+const unset = ""
+
+// CredentialFunc resolves username and password on each re-authentication.
+type CredentialFunc func(ctx context.Context) (*url.Userinfo, error)
+
+type LoginOptions struct {
+	CAFile     string
+	Thumbprint string
+	ServerName string
+	Transport  *TransportOptions
+	RecordDir  string
+	ReplayDir  string
+	Credential CredentialFunc
+}
+
+func defaultLoginOptions() LoginOptions {
+	return LoginOptions{
+		CAFile:     unset,
+		Thumbprint: unset,
+		ServerName: unset,
+		Transport:  nil,
+		RecordDir:  unset,
+		ReplayDir:  unset,
+		Credential: nil,
+	}
+}
+
+func WithCAFile(path string) func(o *LoginOptions) {
+	return func(o *LoginOptions) {
+		o.CAFile = path
+	}
+}
+
+func WithThumbprint(thumbprint string) func(o *LoginOptions) {
+	return func(o *LoginOptions) {
+		o.Thumbprint = thumbprint
+	}
+}
+
+func WithServerName(name string) func(o *LoginOptions) {
+	return func(o *LoginOptions) {
+		o.ServerName = name
+	}
+}
+
+func WithTransport(t *TransportOptions) func(o *LoginOptions) {
+	return func(o *LoginOptions) {
+		o.Transport = t
+	}
+}
+
+func WithRecordDir(dir string) func(o *LoginOptions) {
+	return func(o *LoginOptions) {
+		o.RecordDir = dir
+	}
+}
+
+func WithReplayDir(dir string) func(o *LoginOptions) {
+	return func(o *LoginOptions) {
+		o.ReplayDir = dir
+	}
+}
+
+// WithCredential re-resolves credential on re-authentication to follow password rotation.
+func WithCredential(f CredentialFunc) func(o *LoginOptions) {
+	return func(o *LoginOptions) {
+		o.Credential = f
+	}
+}
+
 func Login(
 	ctx context.Context,
 	endpoint string,
 	username string,
 	password string,
 	noVerifySSL bool,
+	opts ...func(o *LoginOptions),
 ) (*vim25.Client, error) {
+	opt := defaultLoginOptions()
+	for _, o := range opts {
+		o(&opt)
+	}
+
 	u, err := soap.ParseURL(endpoint)
 	if err != nil {
 		return nil, err
 	}
 
 	sc := soap.NewClient(u, noVerifySSL)
+	if err := configureTLS(sc, u, opt); err != nil {
+		return nil, err
+	}
+
+	if err := configureTransport(sc, opt.Transport); err != nil {
+		return nil, err
+	}
+
+	if err := configureRecord(sc, opt); err != nil {
+		return nil, err
+	}
+
 	vc, err := ExecCallAPI(
 		ctx,
 		func(cctx context.Context) (*vim25.Client, error) {
@@ -48,9 +136,31 @@ func Login(
 		return nil, err
 	}
 
+	// Re-authenticate when the session is expired in vSphere server.
+	vc.RoundTripper = newReauthRoundTripper(vc, sc, cred, opt.Credential)
+
 	return vc, nil
 }
 
+func configureTLS(sc *soap.Client, u *url.URL, opt LoginOptions) error {
+	if opt.CAFile != unset {
+		if err := sc.SetRootCAs(opt.CAFile); err != nil {
+			return err
+		}
+	}
+
+	if opt.Thumbprint != unset {
+		// Accept the certificate matched with thumbprint if untrusted.
+		sc.SetThumbprint(u.Host, opt.Thumbprint)
+	}
+
+	if opt.ServerName != unset {
+		sc.DefaultTransport().TLSClientConfig.ServerName = opt.ServerName
+	}
+
+	return nil
+}
+
 func Logout(ctx context.Context, c *vim25.Client) error {
 	sm := session.NewManager(c)
 
@@ -68,6 +178,36 @@ func ExecCallAPI[T any](
 	ctx context.Context,
 	fn func(ctx context.Context) (T, error),
 ) (T, error) {
+	timeout := getTimeout(ctx)
+	policy := getRetryPolicy(ctx)
+
+	backoff := policy.InitialBackoff
+	for attempt := firstAttempt; ; attempt++ {
+		result, err := execCallAPIOnce(ctx, timeout, fn)
+		if err == nil || attempt >= policy.MaxAttempts {
+			return result, err
+		}
+
+		if !waitRetry(ctx, err, attempt, backoff) {
+			return result, err
+		}
+
+		backoff = min(backoff*backoffFactor, policy.MaxBackoff)
+	}
+}
+
+func execCallAPIOnce[T any](
+	ctx context.Context,
+	timeout time.Duration,
+	fn func(ctx context.Context) (T, error),
+) (T, error) {
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return fn(cctx)
+}
+
+func getTimeout(ctx context.Context) time.Duration {
 	timeout, ok := ctx.Value(flag.TargetTimeoutKey{}).(int)
 	//revive:disable:add-constant
 	if !ok || timeout < 0 {
@@ -75,8 +215,5 @@ func ExecCallAPI[T any](
 	}
 	//revive:enable:add-constant
 
-	cctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
-	defer cancel()
-
-	return fn(cctx)
+	return time.Duration(timeout) * time.Second
 }
@@ -0,0 +1,88 @@
+package sessionex
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const nextGeneration = 1
+
+type reauthRoundTripper struct {
+	soap.RoundTripper
+	manager *session.Manager
+	cred    *url.Userinfo
+	resolve CredentialFunc
+	rock    sync.Mutex
+	// generation counts logins to skip login already done by other request.
+	generation atomic.Uint64
+}
+
+func newReauthRoundTripper(
+	vc *vim25.Client,
+	sc *soap.Client,
+	cred *url.Userinfo,
+	resolve CredentialFunc,
+) *reauthRoundTripper {
+	// Login without this round tripper to avoid recursion.
+	c := &vim25.Client{
+		Client:         sc,
+		ServiceContent: vc.ServiceContent,
+		RoundTripper:   sc,
+	}
+
+	return &reauthRoundTripper{
+		RoundTripper: vc.RoundTripper,
+		manager:      session.NewManager(c),
+		cred:         cred,
+		resolve:      resolve,
+	}
+}
+
+func (r *reauthRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	generation := r.generation.Load()
+
+	err := r.RoundTripper.RoundTrip(ctx, req, res)
+	if err == nil || !fault.Is(err, &types.NotAuthenticated{}) {
+		return err
+	}
+
+	r.rock.Lock()
+	defer r.rock.Unlock()
+
+	if r.generation.Load() != generation {
+		// Other request re-authenticated after this request started.
+		return err
+	}
+
+	// Return original error to retry in ExecCallAPI with new session.
+	if loginErr := r.login(ctx); loginErr != nil {
+		slog.WarnContext(ctx, "Could not re-authenticate", "error", loginErr)
+	} else {
+		r.generation.Add(nextGeneration)
+		slog.InfoContext(ctx, "Re-authenticated")
+	}
+
+	return err
+}
+
+func (r *reauthRoundTripper) login(ctx context.Context) error {
+	if r.resolve != nil {
+		cred, err := r.resolve(ctx)
+		if err != nil {
+			return err
+		}
+
+		r.cred = cred
+	}
+
+	return r.manager.Login(ctx, r.cred)
+}
@@ -0,0 +1,111 @@
+package sessionex_test
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+
+	"github.com/9506hqwy/vmomi-exporter/internal/vcsimtest"
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+	sx "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/sessionex"
+)
+
+const (
+	// Retry once with the new session after re-authentication.
+	maxAttempts        = 2
+	noBackoff          = float64(0)
+	resolvedOnce       = int32(1)
+	notResolvedYet     = int32(0)
+	concurrentRequests = 4
+)
+
+// loginExpired logs in with resolver and expires the session in the server.
+func loginExpired(
+	t *testing.T,
+	resolved *atomic.Int32,
+) (context.Context, *vim25.Client) {
+	t.Helper()
+
+	sim := vcsimtest.Start(t)
+	ctx := context.WithValue(sim.Context, flag.TargetRetryMaxAttemptsKey{}, maxAttempts)
+	ctx = context.WithValue(ctx, flag.TargetRetryInitialBackoffKey{}, noBackoff)
+
+	resolve := func(context.Context) (*url.Userinfo, error) {
+		resolved.Add(resolvedOnce)
+		return sim.URL.User, nil
+	}
+
+	password, _ := sim.URL.User.Password()
+	c, err := sx.Login(
+		ctx,
+		sim.URL.String(),
+		sim.URL.User.Username(),
+		password,
+		true,
+		sx.WithCredential(resolve),
+	)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	if n := resolved.Load(); n != notResolvedYet {
+		t.Errorf("Resolved %v times at login", n)
+	}
+
+	if err := session.NewManager(c).Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	return ctx, c
+}
+
+func getCurrentTime(ctx context.Context, c *vim25.Client) error {
+	_, err := sx.ExecCallAPI(
+		ctx,
+		func(cctx context.Context) (*time.Time, error) {
+			return methods.GetCurrentTime(cctx, c)
+		},
+	)
+
+	return err
+}
+
+func TestReauthResolvesCredential(t *testing.T) {
+	var resolved atomic.Int32
+	ctx, c := loginExpired(t, &resolved)
+
+	if err := getCurrentTime(ctx, c); err != nil {
+		t.Fatalf("GetCurrentTime: %v", err)
+	}
+
+	if n := resolved.Load(); n != resolvedOnce {
+		t.Errorf("Resolved %v times at re-authentication", n)
+	}
+}
+
+func TestReauthConcurrentRequests(t *testing.T) {
+	var resolved atomic.Int32
+	ctx, c := loginExpired(t, &resolved)
+
+	var wg sync.WaitGroup
+	for range concurrentRequests {
+		wg.Go(func() {
+			if err := getCurrentTime(ctx, c); err != nil {
+				t.Errorf("GetCurrentTime: %v", err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if n := resolved.Load(); n != resolvedOnce {
+		t.Errorf("Re-authenticated %v times", n)
+	}
+}
@@ -0,0 +1,294 @@
+package sessionex
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+const (
+	empty          = 0
+	recordDirPerm  = 0o700
+	recordFilePerm = 0o600
+	requestSuffix  = ".req.xml"
+	responseSuffix = ".res.xml"
+	redacted       = "REDACTED"
+	soapBody       = "Body"
+	soapFault      = "Fault"
+	step           = 1
+	unknownMethod  = "Unknown"
+	loginMethod    = "Login"
+)
+
+// Redact credentials and session identifiers.
+var (
+	redactPattern = regexp.MustCompile(
+		`(<(?:password|userName|sessionID)(?:\s[^>]*)?>)[^<]*(</(?:password|userName|sessionID)>)`,
+	)
+	redactLoginPattern = regexp.MustCompile(`(<key(?:\s[^>]*)?>)[^<]*(</key>)`)
+)
+
+// Shared across sessions in the process to keep recorded order.
+var recordSeq atomic.Int64
+
+type recordRoundTripper struct {
+	base http.RoundTripper
+	dir  string
+}
+
+type replayExchange struct {
+	request  string
+	response []byte
+}
+
+type replayRoundTripper struct {
+	exchanges map[string][]replayExchange
+	cursors   map[string]int
+	rock      sync.Mutex
+}
+
+func configureRecord(sc *soap.Client, opt LoginOptions) error {
+	if opt.ReplayDir != unset {
+		replay, err := newReplayRoundTripper(opt.ReplayDir)
+		if err != nil {
+			return err
+		}
+
+		sc.Client.Transport = replay
+		return nil
+	}
+
+	if opt.RecordDir != unset {
+		record, err := newRecordRoundTripper(sc.Client.Transport, opt.RecordDir)
+		if err != nil {
+			return err
+		}
+
+		sc.Client.Transport = record
+	}
+
+	return nil
+}
+
+func newRecordRoundTripper(base http.RoundTripper, dir string) (*recordRoundTripper, error) {
+	if err := os.MkdirAll(dir, recordDirPerm); err != nil {
+		return nil, err
+	}
+
+	if err := seedRecordSeq(dir); err != nil {
+		return nil, err
+	}
+
+	return &recordRoundTripper{
+		base: base,
+		dir:  dir,
+	}, nil
+}
+
+func (r *recordRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := readBody(&res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	method := soapMethod(reqBody)
+	name := fmt.Sprintf("%06d-%v", recordSeq.Add(step), method)
+	path := filepath.Join(r.dir, name)
+
+	reqData := redact(method, reqBody)
+	if err := os.WriteFile(path+requestSuffix, reqData, recordFilePerm); err != nil {
+		return nil, err
+	}
+
+	resData := redact(method, resBody)
+	if err := os.WriteFile(path+responseSuffix, resData, recordFilePerm); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Append to existing records instead of overwriting them.
+func seedRecordSeq(dir string) error {
+	requests, err := filepath.Glob(filepath.Join(dir, "*"+requestSuffix))
+	if err != nil {
+		return err
+	}
+
+	recorded := int64(len(requests))
+	for {
+		current := recordSeq.Load()
+		if current >= recorded || recordSeq.CompareAndSwap(current, recorded) {
+			return nil
+		}
+	}
+}
+
+func newReplayRoundTripper(dir string) (*replayRoundTripper, error) {
+	requests, err := filepath.Glob(filepath.Join(dir, "*"+requestSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(requests) == empty {
+		return nil, fmt.Errorf("not found recorded requests in %v", dir)
+	}
+
+	r := &replayRoundTripper{
+		exchanges: map[string][]replayExchange{},
+		cursors:   map[string]int{},
+	}
+
+	slices.Sort(requests)
+	for _, path := range requests {
+		if err := r.load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *replayRoundTripper) load(path string) error {
+	req, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	res, err := os.ReadFile(strings.TrimSuffix(path, requestSuffix) + responseSuffix)
+	if err != nil {
+		return err
+	}
+
+	method := soapMethod(req)
+	r.exchanges[method] = append(r.exchanges[method], replayExchange{
+		request:  string(req),
+		response: res,
+	})
+
+	return nil
+}
+
+func (r *replayRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	method := soapMethod(reqBody)
+	resBody, err := r.find(method, string(redact(method, reqBody)))
+	if err != nil {
+		return nil, err
+	}
+
+	status := http.StatusOK
+	if soapMethod(resBody) == soapFault {
+		status = http.StatusInternalServerError
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %v", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         req.Proto,
+		ProtoMajor:    req.ProtoMajor,
+		ProtoMinor:    req.ProtoMinor,
+		Header:        http.Header{"Content-Type": {"text/xml; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(resBody)),
+		ContentLength: int64(len(resBody)),
+		Request:       req,
+	}, nil
+}
+
+func (r *replayRoundTripper) find(method string, request string) ([]byte, error) {
+	r.rock.Lock()
+	defer r.rock.Unlock()
+
+	exchanges, ok := r.exchanges[method]
+	if !ok {
+		return nil, fmt.Errorf("not recorded %v", method)
+	}
+
+	// Prefer the same request, and fall back to the same method in recorded order.
+	key := method + request
+	candidates := slices.DeleteFunc(slices.Clone(exchanges), func(e replayExchange) bool {
+		return e.request != request
+	})
+	if len(candidates) == empty {
+		key = method
+		candidates = exchanges
+	}
+
+	// Repeat the last response after all responses are replayed.
+	cursor := min(r.cursors[key], len(candidates)-step)
+	r.cursors[key]++
+	return candidates[cursor].response, nil
+}
+
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return []byte{}, nil
+	}
+
+	data, err := io.ReadAll(*body)
+	closeErr := (*body).Close()
+	if err := errors.Join(err, closeErr); err != nil {
+		return nil, err
+	}
+
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func redact(method string, data []byte) []byte {
+	data = redactPattern.ReplaceAll(data, []byte("${1}"+redacted+"${2}"))
+	if method == loginMethod {
+		data = redactLoginPattern.ReplaceAll(data, []byte("${1}"+redacted+"${2}"))
+	}
+
+	return data
+}
+
+// Return the first element name in SOAP body such as `RetrieveProperties`.
+func soapMethod(data []byte) string {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	inBody := false
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return unknownMethod
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if inBody {
+			return start.Name.Local
+		}
+
+		inBody = start.Name.Local == soapBody
+	}
+}
@@ -0,0 +1,180 @@
+package sessionex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/9506hqwy/vmomi-exporter/pkg/flag"
+)
+
+const (
+	RetryReasonFault            = "fault"
+	RetryReasonNetwork          = "network"
+	RetryReasonNotAuthenticated = "not_authenticated"
+	RetryReasonStatus           = "status"
+	RetryReasonTimeout          = "timeout"
+)
+
+const (
+	backoffFactor         = 2
+	defaultInitialBackoff = 1 * time.Second
+	defaultMaxAttempts    = 1
+	defaultMaxBackoff     = 30 * time.Second
+	firstAttempt          = 1
+)
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var retryCounter = struct {
+	rock   sync.Mutex
+	counts map[string]uint64
+}{
+	counts: map[string]uint64{},
+}
+
+func Retries() map[string]uint64 {
+	retryCounter.rock.Lock()
+	defer retryCounter.rock.Unlock()
+
+	return maps.Clone(retryCounter.counts)
+}
+
+func countRetry(reason string) {
+	retryCounter.rock.Lock()
+	defer retryCounter.rock.Unlock()
+
+	retryCounter.counts[reason]++
+}
+
+func getRetryPolicy(ctx context.Context) RetryPolicy {
+	maxAttempts, ok := ctx.Value(flag.TargetRetryMaxAttemptsKey{}).(int)
+	if !ok || maxAttempts < firstAttempt {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	initialBackoff := getDuration(ctx, flag.TargetRetryInitialBackoffKey{}, defaultInitialBackoff)
+	maxBackoff := getDuration(ctx, flag.TargetRetryMaxBackoffKey{}, defaultMaxBackoff)
+
+	return RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     max(initialBackoff, maxBackoff),
+	}
+}
+
+func getDuration(ctx context.Context, key any, defaultValue time.Duration) time.Duration {
+	seconds, ok := ctx.Value(key).(float64)
+	//revive:disable:add-constant
+	if !ok || seconds < 0 {
+		return defaultValue
+	}
+	//revive:enable:add-constant
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func retryReason(ctx context.Context, err error) (string, bool) {
+	if ctx.Err() != nil {
+		// Not retry if canceled or exceeded deadline by caller.
+		return "", false
+	}
+
+	switch {
+	case fault.Is(err, &types.NotAuthenticated{}):
+		return RetryReasonNotAuthenticated, true
+	case fault.IsTransientError(err):
+		return RetryReasonFault, true
+	case isRetryableStatus(err):
+		return RetryReasonStatus, true
+	case errors.Is(err, context.DeadlineExceeded):
+		return RetryReasonTimeout, true
+	case isNetworkError(err):
+		return RetryReasonNetwork, true
+	default:
+		return "", false
+	}
+}
+
+func isRetryableStatus(err error) bool {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || urlErr.Err == nil {
+		return false
+	}
+
+	// soap.Client reports unexpected HTTP status as "<code> <reason>".
+	code, _, _ := strings.Cut(urlErr.Err.Error(), " ")
+	status, convErr := strconv.Atoi(code)
+	if convErr != nil {
+		return false
+	}
+
+	switch status {
+	case
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func isNetworkError(err error) bool {
+	if errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func waitRetry(ctx context.Context, err error, attempt int, backoff time.Duration) bool {
+	reason, retryable := retryReason(ctx, err)
+	if !retryable {
+		return false
+	}
+
+	countRetry(reason)
+	slog.DebugContext(
+		ctx,
+		"Retry",
+		"reason", reason,
+		"attempt", attempt,
+		"backoff", backoff,
+		"error", err,
+	)
+
+	return sleepContext(ctx, backoff)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
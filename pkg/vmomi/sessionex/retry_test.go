@@ -0,0 +1,73 @@
+package sessionex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	post   = "Post"
+	sdkURL = "https://127.0.0.1/sdk"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func statusError(status string) error {
+	return &url.Error{Op: post, URL: sdkURL, Err: errors.New(status)}
+}
+
+func TestRetryReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{
+			"not authenticated",
+			soap.WrapVimFault(&types.NotAuthenticated{}),
+			RetryReasonNotAuthenticated,
+		},
+		{"transient fault", soap.WrapVimFault(&types.ConcurrentAccess{}), RetryReasonFault},
+		{"429", statusError("429 Too Many Requests"), RetryReasonStatus},
+		{"502", statusError("502 Bad Gateway"), RetryReasonStatus},
+		{"503", statusError("503 Service Unavailable"), RetryReasonStatus},
+		{"504", statusError("504 Gateway Timeout"), RetryReasonStatus},
+		{"timeout", fmt.Errorf("call: %w", context.DeadlineExceeded), RetryReasonTimeout},
+		{"net error", &url.Error{Op: post, URL: sdkURL, Err: timeoutError{}}, RetryReasonNetwork},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), RetryReasonNetwork},
+		{"non-retryable fault", soap.WrapVimFault(&types.InvalidArgument{}), ""},
+		{"non-retryable status", statusError("500 Internal Server Error"), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reason, retryable := retryReason(t.Context(), test.err)
+			if reason != test.reason || retryable != (test.reason != "") {
+				t.Errorf("retryReason = %v, %v", reason, retryable)
+			}
+		})
+	}
+}
+
+func TestRetryReasonCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, retryable := retryReason(ctx, context.Canceled); retryable {
+		t.Error("Retry canceled call")
+	}
+}
@@ -0,0 +1,79 @@
+package sessionex
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"golang.org/x/net/http/httpproxy"
+)
+
+const unlimited = 0
+
+type TransportOptions struct {
+	ProxyURL            string
+	NoProxy             string
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DisableKeepAlives   bool
+	UserAgent           string
+	HTTP2               bool
+}
+
+func configureTransport(sc *soap.Client, opt *TransportOptions) error {
+	if opt == nil {
+		return nil
+	}
+
+	t := sc.DefaultTransport()
+
+	if err := configureProxy(t, opt); err != nil {
+		return err
+	}
+
+	if opt.MaxIdleConns > unlimited {
+		t.MaxIdleConns = opt.MaxIdleConns
+	}
+
+	if opt.MaxIdleConnsPerHost > unlimited {
+		t.MaxIdleConnsPerHost = opt.MaxIdleConnsPerHost
+	}
+
+	if opt.IdleConnTimeout > unlimited {
+		t.IdleConnTimeout = opt.IdleConnTimeout
+	}
+
+	t.DisableKeepAlives = opt.DisableKeepAlives
+	t.ForceAttemptHTTP2 = opt.HTTP2
+
+	if opt.UserAgent != unset {
+		sc.UserAgent = opt.UserAgent
+	}
+
+	return nil
+}
+
+func configureProxy(t *http.Transport, opt *TransportOptions) error {
+	if opt.ProxyURL == unset {
+		return nil
+	}
+
+	if _, err := url.Parse(opt.ProxyURL); err != nil {
+		return err
+	}
+
+	proxy := httpproxy.Config{
+		HTTPProxy:  opt.ProxyURL,
+		HTTPSProxy: opt.ProxyURL,
+		NoProxy:    opt.NoProxy,
+	}
+
+	proxyFunc := proxy.ProxyFunc()
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	return nil
+}
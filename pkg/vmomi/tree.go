@@ -0,0 +1,182 @@
+package vmomi
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+
+	px "github.com/9506hqwy/vmomi-exporter/pkg/vmomi/propertyex"
+)
+
+const (
+	datastorePath = "datastore"
+	networkPath   = "network"
+)
+
+type EntityNode struct {
+	Entity    Entity
+	Children  []*EntityNode
+	Relations []Entity
+}
+
+type treeObject struct {
+	node      *EntityNode
+	parent    *types.ManagedObjectReference
+	relations []types.ManagedObjectReference
+}
+
+// GetEntityTree returns inventory hierarchy from root folder.
+// Virtual machine is placed under running host instead of folder.
+func GetEntityTree(ctx context.Context) (*EntityNode, error) {
+	c, err := login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer logout(ctx, c)
+
+	objects, err := getTreeObjects(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := objects[c.ServiceContent.RootFolder]
+	if !ok {
+		return nil, nil
+	}
+
+	linkTreeObjects(objects)
+	return root.node, nil
+}
+
+// Find returns nodes matched in the subtree.
+func (n *EntityNode) Find(match func(e Entity) bool) []*EntityNode {
+	found := []*EntityNode{}
+	if match(n.Entity) {
+		found = append(found, n)
+	}
+
+	for _, child := range n.Children {
+		found = append(found, child.Find(match)...)
+	}
+
+	return found
+}
+
+func getTreeObjects(
+	ctx context.Context,
+	c *vim25.Client,
+) (map[types.ManagedObjectReference]*treeObject, error) {
+	pathSets := map[string][]string{}
+	for _, t := range ManagedEntityTypeValues() {
+		pathSets[string(t)] = []string{namePath, parentPath}
+	}
+
+	pathSets[string(ManagedEntityTypeHostSystem)] = []string{
+		namePath,
+		parentPath,
+		datastorePath,
+		networkPath,
+	}
+
+	pathSets[string(ManagedEntityTypeVirtualMachine)] = []string{
+		namePath,
+		parentPath,
+		runtimeHostPath,
+		datastorePath,
+		networkPath,
+	}
+
+	roots := []types.ManagedObjectReference{c.ServiceContent.RootFolder}
+	contents, err := px.RetrieveWithPathSet(ctx, c, roots, pathSets, true)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := map[types.ManagedObjectReference]*treeObject{}
+	for _, content := range contents {
+		objects[content.Obj] = toTreeObject(content)
+	}
+
+	return objects, nil
+}
+
+func toTreeObject(content types.ObjectContent) *treeObject {
+	obj := treeObject{
+		node: &EntityNode{
+			Entity: Entity{
+				ID:   content.Obj.Value,
+				Name: "",
+				Type: ManagedEntityType(content.Obj.Type),
+			},
+			Children:  []*EntityNode{},
+			Relations: []Entity{},
+		},
+		parent:    nil,
+		relations: []types.ManagedObjectReference{},
+	}
+
+	var host *types.ManagedObjectReference
+	for _, prop := range content.PropSet {
+		switch v := prop.Val.(type) {
+		case string:
+			obj.node.Entity.Name = v
+		case types.ManagedObjectReference:
+			if prop.Name == runtimeHostPath {
+				host = &v
+			} else {
+				obj.parent = &v
+			}
+		case types.ArrayOfManagedObjectReference:
+			obj.relations = append(obj.relations, v.ManagedObjectReference...)
+		}
+	}
+
+	// Prefer running host to show compute hierarchy.
+	obj.parent = cmp.Or(host, obj.parent)
+	return &obj
+}
+
+func linkTreeObjects(objects map[types.ManagedObjectReference]*treeObject) {
+	for _, obj := range objects {
+		linkParent(objects, obj)
+		linkRelations(objects, obj)
+	}
+
+	for _, obj := range objects {
+		slices.SortFunc(obj.node.Children, func(a, b *EntityNode) int {
+			return compareEntity(a.Entity, b.Entity)
+		})
+
+		slices.SortFunc(obj.node.Relations, compareEntity)
+	}
+}
+
+func linkParent(objects map[types.ManagedObjectReference]*treeObject, obj *treeObject) {
+	if obj.parent == nil {
+		return
+	}
+
+	if parent, ok := objects[*obj.parent]; ok {
+		parent.node.Children = append(parent.node.Children, obj.node)
+	}
+}
+
+func linkRelations(objects map[types.ManagedObjectReference]*treeObject, obj *treeObject) {
+	for _, ref := range obj.relations {
+		if related, ok := objects[ref]; ok {
+			obj.node.Relations = append(obj.node.Relations, related.node.Entity)
+		}
+	}
+}
+
+func compareEntity(a, b Entity) int {
+	return cmp.Or(
+		cmp.Compare(a.Type, b.Type),
+		cmp.Compare(a.Name, b.Name),
+		cmp.Compare(a.ID, b.ID),
+	)
+}